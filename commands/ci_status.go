@@ -1,30 +1,99 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/jingweno/gh/git"
 	"github.com/jingweno/gh/github"
 	"github.com/jingweno/gh/utils"
 	"os"
+	"regexp"
+	"strings"
+	"time"
 )
 
-var cmdCiStatus = &Command{
-	Run:   ciStatus,
-	Usage: "ci-status [-v] [COMMIT]",
-	Short: "Show CI status of a commit",
-	Long: `Looks up the SHA for <COMMIT> in GitHub Status API and displays the latest
-status. Exits with one of:
+var (
+	cmdCiStatus = &Command{
+		Run:           ciStatus,
+		AcceptsParams: true,
+		Usage:         "ci-status [-v] [--format=<FORMAT>] [--watch [--interval=<INTERVAL>] [--timeout=<TIMEOUT>]] [COMMIT...|--all-branches]",
+		Short:         "Show CI status of a commit",
+		Long: `Looks up the SHA for <COMMIT> in GitHub Status API and Checks API and displays
+the combined status of all the CI contexts reporting on it, whether as commit
+statuses or as check runs: "failure" or "error" if any context failed, "pending"
+if any context is still running, and "success" only when every context
+succeeded. Neutral and skipped check runs count as successful. Exits with one of:
 success (0), error (1), failure (1), pending (2), no status (3)
 
-If "-v" is given, additionally print the URL to CI build results.
-`,
-}
+<COMMIT> is HEAD by default. A pull request number, as in "#123", or a pull
+request URL can be given as well, in which case the head of the pull request is
+looked up in GitHub and doesn't need to be fetched. A number without "#", as in
+"123", is taken as a pull request number only when it isn't a local ref.
+
+Given several <COMMIT>s, or "--all-branches" for the upstream of every local
+branch that has one, it displays a table with the state of each and exits with
+the code of the worst state among them.
+
+If "-v" is given, additionally list every context with its state, or the
+conclusion of a check run, its description and the URL to its build results,
+followed by the annotations of each check run.
+
+With "--format=json", the status is printed as JSON instead, with the SHA, the
+combined state and the state, result, description, target URL and timestamps
+of each context: "created_at" and "updated_at" for a commit status, and
+"started_at" and, once it's completed, "completed_at" for a check run. Several
+<COMMIT>s, or "--all-branches", are printed as an array. The exit code is the
+same as with the default "--format=text".
+
+With "--watch", it polls the status every <INTERVAL>, 10s by default, until
+it's no longer pending and then exits as above. It only works with a single
+<COMMIT>. It gives up after <TIMEOUT>, 30m by default, and exits with the
+pending code; a <TIMEOUT> of 0 waits indefinitely. On a terminal, the state of
+every context is redrawn in place after each poll. Polling goes on while the
+commit has no status yet, as just after a push, and after a poll fails.
+`}
+
+	cmdSetCiStatus = &Command{
+		Key:   "set",
+		Run:   setCiStatus,
+		Usage: "ci-status set [-c <CONTEXT>] [-u <TARGET_URL>] [-d <DESCRIPTION>] <STATE> [COMMIT]",
+		Short: "Set CI status of a commit",
+		Long: `Creates a status for <COMMIT>, HEAD by default, in GitHub Status API.
+<STATE> is one of "success", "failure", "error" or "pending". Use "-c" to name
+the context the status is reported for, "-u" to link to the build results and
+"-d" to describe the status. <COMMIT> can be a pull request number or URL as
+with "ci-status".
+`}
+)
 
-var flagCiStatusVerbose bool
+var (
+	flagCiStatusVerbose,
+	flagCiStatusWatch,
+	flagCiStatusAllBranches bool
+
+	flagCiStatusContext,
+	flagCiStatusTargetURL,
+	flagCiStatusDescription,
+	flagCiStatusFormat string
+
+	flagCiStatusInterval,
+	flagCiStatusTimeout time.Duration
+)
 
 func init() {
 	cmdCiStatus.Flag.BoolVarP(&flagCiStatusVerbose, "verbose", "v", false, "VERBOSE")
+	cmdCiStatus.Flag.BoolVar(&flagCiStatusWatch, "watch", false, "WATCH")
+	cmdCiStatus.Flag.BoolVar(&flagCiStatusAllBranches, "all-branches", false, "ALL_BRANCHES")
+	cmdCiStatus.Flag.StringVar(&flagCiStatusFormat, "format", "text", "FORMAT")
+	cmdCiStatus.Flag.DurationVar(&flagCiStatusInterval, "interval", 10*time.Second, "INTERVAL")
+	cmdCiStatus.Flag.DurationVar(&flagCiStatusTimeout, "timeout", 30*time.Minute, "TIMEOUT")
 
+	cmdSetCiStatus.Flag.StringVarP(&flagCiStatusContext, "context", "c", "", "CONTEXT")
+	cmdSetCiStatus.Flag.StringVarP(&flagCiStatusTargetURL, "target-url", "u", "", "TARGET_URL")
+	cmdSetCiStatus.Flag.StringVarP(&flagCiStatusDescription, "description", "d", "", "DESCRIPTION")
+
+	cmdCiStatus.Use(cmdSetCiStatus)
 	CmdRunner.Use(cmdCiStatus)
 }
 
@@ -34,7 +103,11 @@ func init() {
   > One of: success (0), error (1), failure (1), pending (2), no status (3)
 
   $ gh ci-status -v
-  > (prints CI state of HEAD, the URL to the CI build results and exits with appropriate code)
+  > (prints CI state of HEAD, the state, description and URL of each CI context and exits with appropriate code)
+  > One of: success (0), error (1), failure (1), pending (2), no status (3)
+
+  $ gh ci-status --watch --interval=30s --timeout=1h
+  > (polls CI state of HEAD every 30s until it's no longer pending or an hour has passed, and exits with appropriate code)
   > One of: success (0), error (1), failure (1), pending (2), no status (3)
 
   $ gh ci-status BRANCH
@@ -44,8 +117,192 @@ func init() {
   $ gh ci-status SHA
   > (prints CI state of SHA and exits with appropriate code)
   > One of: success (0), error (1), failure (1), pending (2), no status (3)
+
+  $ gh ci-status '#123'
+  $ gh ci-status https://github.com/jingweno/gh/pull/123
+  > (prints CI state of the head of pull request #123 and exits with appropriate code)
+  > One of: success (0), error (1), failure (1), pending (2), no status (3)
+
+  $ gh ci-status master feature
+  $ gh ci-status --all-branches
+  > (prints a table of the CI state of each branch and exits with the code of the worst state)
+  > One of: success (0), error (1), failure (1), pending (2), no status (3)
 */
 func ciStatus(cmd *Command, args *Args) {
+	refs := args.Params
+	if flagCiStatusAllBranches {
+		if len(refs) > 0 {
+			utils.Check(fmt.Errorf("COMMIT can't be given with --all-branches"))
+		}
+
+		var err error
+		refs, err = upstreamBranchNames()
+		utils.Check(err)
+		if len(refs) == 0 {
+			utils.Check(fmt.Errorf("Aborted: no local branch has an upstream"))
+		}
+	}
+	if len(refs) == 0 {
+		refs = []string{"HEAD"}
+	}
+
+	if flagCiStatusWatch && len(refs) > 1 {
+		utils.Check(fmt.Errorf("--watch only works with a single COMMIT"))
+	}
+
+	localRepo := github.LocalRepo()
+	project, err := localRepo.MainProject()
+	utils.Check(err)
+
+	var commits []ciCommit
+	for _, ref := range refs {
+		var commit ciCommit
+		if flagCiStatusAllBranches {
+			commit, err = resolveCiCommit(project, fmt.Sprintf("%s@{upstream}", ref))
+			commit.Name = ref
+		} else {
+			commit, err = resolveCiCommit(project, ref)
+		}
+		utils.Check(err)
+
+		commits = append(commits, commit)
+	}
+
+	if args.Noop {
+		for _, commit := range commits {
+			fmt.Printf("Would request CI status for %s\n", commit.Sha)
+		}
+		return
+	}
+
+	if flagCiStatusFormat != "text" && flagCiStatusFormat != "json" {
+		utils.Check(fmt.Errorf("Unknown format: %s (use text or json)", flagCiStatusFormat))
+	}
+
+	if len(commits) == 1 && !flagCiStatusAllBranches {
+		commit := commits[0]
+		if flagCiStatusWatch {
+			os.Exit(watchCiStatus(commit))
+		}
+
+		var exitCode int
+		commit.State, commit.Contexts, exitCode, err = fetchCiStatus(commit.Project, commit.Sha, flagCiStatusVerbose)
+		utils.Check(err)
+
+		if flagCiStatusFormat == "json" {
+			out, err := formatCiStatusJSON(commit)
+			utils.Check(err)
+			fmt.Print(out)
+		} else {
+			fmt.Print(formatCiStatus(commit.State, commit.Contexts, flagCiStatusVerbose))
+		}
+
+		os.Exit(exitCode)
+	}
+
+	var states []string
+	for i, commit := range commits {
+		commits[i].State, commits[i].Contexts, _, err = fetchCiStatus(commit.Project, commit.Sha, flagCiStatusVerbose)
+		utils.Check(err)
+
+		states = append(states, commits[i].State)
+	}
+
+	if flagCiStatusFormat == "json" {
+		out, err := formatCiStatusJSON(commits)
+		utils.Check(err)
+		fmt.Print(out)
+	} else {
+		fmt.Print(formatCiStatusTable(commits, flagCiStatusVerbose))
+	}
+
+	os.Exit(ciStatusExitCode(ciCombinedState(states)))
+}
+
+type ciCommit struct {
+	Name     string          `json:"ref"`
+	Sha      string          `json:"sha"`
+	Project  *github.Project `json:"-"`
+	State    string          `json:"state"`
+	Contexts []ciContext     `json:"contexts"`
+}
+
+// Pull requests are given by number, as in "#123", or by URL, and are
+// resolved to the SHA of their head through the API so that they don't need
+// to be fetched. Anything else is resolved locally, and a number such as
+// "123" that isn't a local ref is taken as a pull request number.
+func resolveCiCommit(project *github.Project, ref string) (commit ciCommit, err error) {
+	commit = ciCommit{Name: ref, Project: project}
+
+	var id string
+	if match := regexp.MustCompile(`^#(\d+)$`).FindStringSubmatch(ref); match != nil {
+		id = match[1]
+	} else if url, e := github.ParseURL(ref); e == nil {
+		if match := regexp.MustCompile(`^pull/(\d+)`).FindStringSubmatch(url.ProjectPath()); match != nil {
+			id = match[1]
+			commit.Project = url.Project
+		}
+	}
+
+	if id == "" {
+		commit.Sha, err = git.Ref(ref)
+		if err == nil {
+			return
+		}
+
+		if !regexp.MustCompile(`^\d+$`).MatchString(ref) {
+			err = fmt.Errorf("Aborted: no revision could be determined from '%s'", ref)
+			return
+		}
+		id = ref
+	}
+
+	gh := github.NewClient(commit.Project.Host)
+	pullRequest, err := gh.PullRequest(commit.Project, id)
+	if err != nil {
+		return
+	}
+
+	commit.Sha = pullRequest.Head.Sha
+
+	return
+}
+
+// Each local branch is checked at its upstream, which is what was pushed and
+// built by CI.
+func upstreamBranchNames() (names []string, err error) {
+	branches, err := git.LocalBranches()
+	if err != nil {
+		return
+	}
+
+	for _, name := range branches {
+		branch := &github.Branch{Name: name}
+		if _, e := branch.Upstream(); e == nil {
+			names = append(names, branch.ShortName())
+		}
+	}
+
+	return
+}
+
+/*
+  $ gh ci-status set success -c deploy -u https://ci.example.com/builds/1 -d "Deployed to staging"
+  > (creates a successful "deploy" status for HEAD)
+
+  $ gh ci-status set pending 123
+  > (creates a pending status for the head of pull request #123)
+*/
+func setCiStatus(cmd *Command, args *Args) {
+	if args.IsParamsEmpty() {
+		utils.Check(fmt.Errorf("Missed argument STATE"))
+	}
+
+	state := args.RemoveParam(0)
+	if !isCiStatusState(state) {
+		utils.Check(fmt.Errorf("Unknown state: %s (use success, failure, error or pending)", state))
+	}
+
 	ref := "HEAD"
 	if !args.IsParamsEmpty() {
 		ref = args.RemoveParam(0)
@@ -55,51 +312,337 @@ func ciStatus(cmd *Command, args *Args) {
 	project, err := localRepo.MainProject()
 	utils.Check(err)
 
-	sha, err := git.Ref(ref)
-	if err != nil {
-		err = fmt.Errorf("Aborted: no revision could be determined from '%s'", ref)
-	}
+	commit, err := resolveCiCommit(project, ref)
 	utils.Check(err)
 
 	if args.Noop {
-		fmt.Printf("Would request CI status for %s\n", sha)
-	} else {
-		state, targetURL, exitCode, err := fetchCiStatus(project, sha)
-		utils.Check(err)
-		if flagCiStatusVerbose && targetURL != "" {
-			fmt.Printf("%s: %s\n", state, targetURL)
+		fmt.Printf("Would set CI status of %s to %s\n", commit.Sha, state)
+		os.Exit(0)
+	}
+
+	params := github.CIStatus{
+		State:       state,
+		Context:     flagCiStatusContext,
+		Description: flagCiStatusDescription,
+		TargetURL:   flagCiStatusTargetURL,
+	}
+
+	gh := github.NewClient(commit.Project.Host)
+	status, err := gh.CreateCIStatus(commit.Project, commit.Sha, params)
+	utils.Check(err)
+
+	fmt.Print(formatCiContexts(ciContexts([]github.CIStatus{*status}, nil)))
+	os.Exit(0)
+}
+
+func isCiStatusState(state string) bool {
+	switch state {
+	case "success", "failure", "error", "pending":
+		return true
+	}
+
+	return false
+}
+
+// Polls until the state is no longer pending. A commit without status yet,
+// as just after a push, and a failed poll are polled again as well, until
+// the timeout. On a terminal, the state and the table of contexts are
+// redrawn in place after each poll; otherwise only the final state is
+// printed so that the output stays the same as without "--watch".
+func watchCiStatus(commit ciCommit) int {
+	if flagCiStatusInterval <= 0 {
+		utils.Check(fmt.Errorf("The watch interval must be positive: %s", flagCiStatusInterval))
+	}
+
+	isJSON := flagCiStatusFormat == "json"
+	redraw := !isJSON && github.IsTerminal(os.Stdout.Fd())
+	deadline := time.Now().Add(flagCiStatusTimeout)
+	printedLines := 0
+
+	printFinal := func() {
+		if isJSON {
+			out, err := formatCiStatusJSON(commit)
+			utils.Check(err)
+			fmt.Print(out)
+		} else if !redraw {
+			fmt.Print(formatCiStatus(commit.State, commit.Contexts, flagCiStatusVerbose))
+		}
+	}
+
+	exitCode := ciStatusExitCode("no status")
+	for {
+		state, contexts, code, err := fetchCiStatus(commit.Project, commit.Sha, flagCiStatusVerbose)
+		if err == nil {
+			commit.State, commit.Contexts, exitCode = state, contexts, code
+
+			if redraw {
+				out := formatCiStatus(commit.State, commit.Contexts, true)
+				fmt.Print(clearLines(printedLines) + out)
+				printedLines = strings.Count(out, "\n")
+			}
+
+			if commit.State != "pending" && commit.State != "no status" {
+				printFinal()
+
+				return exitCode
+			}
 		} else {
-			fmt.Println(state)
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+			printedLines = 0
 		}
 
-		os.Exit(exitCode)
+		if flagCiStatusTimeout > 0 && time.Now().Add(flagCiStatusInterval).After(deadline) {
+			if commit.State == "" {
+				utils.Check(err)
+			}
+			printFinal()
+			fmt.Fprintf(os.Stderr, "Timed out after %s waiting for CI to finish\n", flagCiStatusTimeout)
+
+			return exitCode
+		}
+
+		time.Sleep(flagCiStatusInterval)
+	}
+}
+
+// Moves the cursor up to the first of the n lines printed last and clears
+// them, so that they can be printed again in place.
+func clearLines(n int) string {
+	if n == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\033[%dA\033[J", n)
+}
+
+func formatCiStatusTable(commits []ciCommit, verbose bool) string {
+	nameWidth := 0
+	for _, commit := range commits {
+		if len(commit.Name) > nameWidth {
+			nameWidth = len(commit.Name)
+		}
+	}
+
+	buffer := bytes.NewBufferString("")
+	for _, commit := range commits {
+		sha := commit.Sha
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		fmt.Fprintf(buffer, "%-*s  %s  %s\n", nameWidth, commit.Name, sha, commit.State)
+
+		if verbose {
+			for _, line := range strings.SplitAfter(formatCiContexts(commit.Contexts), "\n") {
+				if line != "" {
+					fmt.Fprintf(buffer, "    %s", line)
+				}
+			}
+		}
+	}
+
+	return buffer.String()
+}
+
+// A single commit is formatted as an object, and the commits of several refs
+// or of "--all-branches" as an array, even if there's only one of them.
+func formatCiStatusJSON(v interface{}) (string, error) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
 	}
+
+	return string(out) + "\n", nil
 }
 
-func fetchCiStatus(p *github.Project, sha string) (state, targetURL string, exitCode int, err error) {
+func formatCiStatus(state string, contexts []ciContext, verbose bool) string {
+	out := state + "\n"
+	if verbose {
+		out += formatCiContexts(contexts)
+	}
+
+	return out
+}
+
+// Commit statuses and check runs are both reported as CI contexts. The
+// annotations of check runs are only fetched when they're shown.
+func fetchCiStatus(p *github.Project, sha string, withAnnotations bool) (state string, contexts []ciContext, exitCode int, err error) {
 	gh := github.NewClient(p.Host)
 	status, err := gh.CIStatus(p, sha)
 	if err != nil {
 		return
 	}
 
-	if status == nil {
-		state = "no status"
-	} else {
-		state = status.State
-		targetURL = status.TargetURL
+	// Without the Checks API, only the statuses are reported
+	checkRuns, err := gh.CheckRuns(p, sha)
+	if err != nil {
+		return
 	}
 
+	contexts = ciContexts(status.Statuses, checkRuns)
+	if withAnnotations {
+		for i, checkRun := range checkRuns {
+			if checkRun.Output.AnnotationsCount == 0 {
+				continue
+			}
+
+			contexts[len(status.Statuses)+i].Annotations, err = gh.CheckRunAnnotations(p, &checkRuns[i])
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	state = ciAggregateState(contexts)
+	exitCode = ciStatusExitCode(state)
+
+	return
+}
+
+type ciContext struct {
+	// One of the commit status states that the exit code is mapped from
+	State string `json:"state"`
+	// The state as reported, such as the conclusion of a check run
+	Result      string `json:"result"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	TargetURL   string `json:"target_url"`
+	// Commit statuses are created and updated, check runs start and complete
+	CreatedAt   *time.Time               `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time               `json:"updated_at,omitempty"`
+	StartedAt   *time.Time               `json:"started_at,omitempty"`
+	CompletedAt *time.Time               `json:"completed_at,omitempty"`
+	Annotations []github.CheckAnnotation `json:"annotations,omitempty"`
+}
+
+// Statuses come first, then check runs. Check suites aren't reported: GitHub
+// creates a queued suite for every installed app, including the apps that
+// never run, and the suites that do run are reported through their check
+// runs.
+func ciContexts(statuses []github.CIStatus, checkRuns []github.CheckRun) (contexts []ciContext) {
+	for _, status := range statuses {
+		contexts = append(contexts, ciContext{
+			State:       status.State,
+			Result:      status.State,
+			Name:        status.Context,
+			Description: status.Description,
+			TargetURL:   status.TargetURL,
+			CreatedAt:   status.CreatedAt,
+			UpdatedAt:   status.UpdatedAt,
+		})
+	}
+
+	for _, checkRun := range checkRuns {
+		context := ciContext{
+			State:       checkRunState(checkRun.Status, checkRun.Conclusion),
+			Result:      checkRun.Status,
+			Name:        checkRun.Name,
+			Description: checkRun.Output.Title,
+			TargetURL:   checkRun.DetailsURL,
+			StartedAt:   checkRun.StartedAt,
+			CompletedAt: checkRun.CompletedAt,
+		}
+		if checkRun.Conclusion != "" {
+			context.Result = checkRun.Conclusion
+		}
+		if context.TargetURL == "" {
+			context.TargetURL = checkRun.HTMLURL
+		}
+
+		contexts = append(contexts, context)
+	}
+
+	return
+}
+
+// Neutral and skipped check runs don't fail the commit, while timed out,
+// cancelled and the other unsuccessful conclusions do.
+func checkRunState(status, conclusion string) string {
+	if status != "completed" {
+		return "pending"
+	}
+
+	switch conclusion {
+	case "success", "neutral", "skipped":
+		return "success"
+	case "stale":
+		return "error"
+	}
+
+	return "failure"
+}
+
+// A failed context fails the commit regardless of the order in which the
+// contexts reported, and the commit is pending until every context is done.
+func ciAggregateState(contexts []ciContext) string {
+	if len(contexts) == 0 {
+		return "no status"
+	}
+
+	counts := make(map[string]int)
+	for _, context := range contexts {
+		counts[context.State]++
+	}
+
+	for _, state := range []string{"failure", "error", "pending"} {
+		if counts[state] > 0 {
+			return state
+		}
+	}
+
+	return "success"
+}
+
+// The combined state of several commits is the state of the worst one, where a
+// commit without status is only worse than a successful one.
+func ciCombinedState(states []string) string {
+	for _, state := range []string{"failure", "error", "pending", "no status"} {
+		for _, s := range states {
+			if s == state {
+				return state
+			}
+		}
+	}
+
+	return "success"
+}
+
+func ciStatusExitCode(state string) int {
 	switch state {
 	case "success":
-		exitCode = 0
+		return 0
 	case "failure", "error":
-		exitCode = 1
+		return 1
 	case "pending":
-		exitCode = 2
-	default:
-		exitCode = 3
+		return 2
 	}
 
-	return
+	return 3
+}
+
+func formatCiContexts(contexts []ciContext) string {
+	resultWidth, nameWidth := 0, 0
+	for _, context := range contexts {
+		if len(context.Result) > resultWidth {
+			resultWidth = len(context.Result)
+		}
+		if len(context.Name) > nameWidth {
+			nameWidth = len(context.Name)
+		}
+	}
+
+	buffer := bytes.NewBufferString("")
+	for _, context := range contexts {
+		line := fmt.Sprintf("%-*s  %-*s  %s  %s", resultWidth, context.Result, nameWidth, context.Name, context.Description, context.TargetURL)
+		fmt.Fprintln(buffer, strings.TrimRight(line, " "))
+
+		for _, annotation := range context.Annotations {
+			message := annotation.Message
+			if annotation.Title != "" {
+				message = fmt.Sprintf("%s: %s", annotation.Title, message)
+			}
+			fmt.Fprintf(buffer, "    %s:%d: %s: %s\n", annotation.Path, annotation.StartLine, annotation.AnnotationLevel, message)
+		}
+	}
+
+	return buffer.String()
 }
@@ -0,0 +1,255 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	"github.com/jingweno/gh/github"
+)
+
+func TestCiAggregateState(t *testing.T) {
+	assert.Equal(t, "no status", ciAggregateState(nil))
+
+	contexts := []ciContext{
+		{State: "success", Name: "travis"},
+		{State: "pending", Name: "coveralls"},
+	}
+	assert.Equal(t, "pending", ciAggregateState(contexts))
+
+	contexts = append(contexts, ciContext{State: "failure", Name: "jenkins"})
+	assert.Equal(t, "failure", ciAggregateState(contexts))
+
+	contexts = []ciContext{
+		{State: "success", Name: "travis"},
+		{State: "success", Name: "jenkins"},
+	}
+	assert.Equal(t, "success", ciAggregateState(contexts))
+}
+
+func TestCiStatusExitCode(t *testing.T) {
+	assert.Equal(t, 0, ciStatusExitCode("success"))
+	assert.Equal(t, 1, ciStatusExitCode("failure"))
+	assert.Equal(t, 1, ciStatusExitCode("error"))
+	assert.Equal(t, 2, ciStatusExitCode("pending"))
+	assert.Equal(t, 3, ciStatusExitCode("no status"))
+}
+
+func TestCheckRunState(t *testing.T) {
+	assert.Equal(t, "pending", checkRunState("queued", ""))
+	assert.Equal(t, "pending", checkRunState("in_progress", ""))
+	assert.Equal(t, "success", checkRunState("completed", "success"))
+	assert.Equal(t, "success", checkRunState("completed", "neutral"))
+	assert.Equal(t, "success", checkRunState("completed", "skipped"))
+	assert.Equal(t, "failure", checkRunState("completed", "timed_out"))
+	assert.Equal(t, "failure", checkRunState("completed", "cancelled"))
+	assert.Equal(t, "error", checkRunState("completed", "stale"))
+}
+
+func TestCiContexts(t *testing.T) {
+	statuses := []github.CIStatus{
+		{State: "success", Context: "travis", TargetURL: "https://travis-ci.org/jingweno/gh/builds/1"},
+	}
+
+	checkRuns := make([]github.CheckRun, 2)
+	checkRuns[0].Name = "lint"
+	checkRuns[0].Status = "completed"
+	checkRuns[0].Conclusion = "neutral"
+	checkRuns[0].HTMLURL = "https://github.com/jingweno/gh/runs/1"
+	checkRuns[1].Name = "test"
+	checkRuns[1].Status = "in_progress"
+	checkRuns[1].DetailsURL = "https://ci.example.com/2"
+	checkRuns[1].Output.Title = "Running tests"
+
+	contexts := ciContexts(statuses, checkRuns)
+	assert.Equal(t, []ciContext{
+		{State: "success", Result: "success", Name: "travis", TargetURL: "https://travis-ci.org/jingweno/gh/builds/1"},
+		{State: "success", Result: "neutral", Name: "lint", TargetURL: "https://github.com/jingweno/gh/runs/1"},
+		{State: "pending", Result: "in_progress", Name: "test", Description: "Running tests", TargetURL: "https://ci.example.com/2"},
+	}, contexts)
+	assert.Equal(t, "pending", ciAggregateState(contexts))
+}
+
+func TestFormatCiContexts(t *testing.T) {
+	contexts := []ciContext{
+		{State: "success", Result: "success", Name: "travis", Description: "The build passed", TargetURL: "https://travis-ci.org/jingweno/gh/builds/1"},
+		{State: "failure", Result: "timed_out", Name: "ci/jenkins", Annotations: []github.CheckAnnotation{
+			{Path: "main.go", StartLine: 12, AnnotationLevel: "failure", Message: "undefined: foo"},
+			{Path: "main_test.go", StartLine: 3, AnnotationLevel: "warning", Title: "vet", Message: "unused variable"},
+		}},
+	}
+
+	expected := "success    travis      The build passed  https://travis-ci.org/jingweno/gh/builds/1\n" +
+		"timed_out  ci/jenkins\n" +
+		"    main.go:12: failure: undefined: foo\n" +
+		"    main_test.go:3: warning: vet: unused variable\n"
+	assert.Equal(t, expected, formatCiContexts(contexts))
+}
+
+func TestFormatCiStatus(t *testing.T) {
+	contexts := []ciContext{{State: "pending", Result: "pending", Name: "travis"}}
+
+	assert.Equal(t, "pending\n", formatCiStatus("pending", contexts, false))
+	assert.Equal(t, "pending\npending  travis\n", formatCiStatus("pending", contexts, true))
+	assert.Equal(t, "no status\n", formatCiStatus("no status", nil, true))
+}
+
+func TestClearLines(t *testing.T) {
+	assert.Equal(t, "", clearLines(0))
+	assert.Equal(t, "\033[3A\033[J", clearLines(3))
+}
+
+func TestCiCombinedState(t *testing.T) {
+	assert.Equal(t, "success", ciCombinedState([]string{"success", "success"}))
+	assert.Equal(t, "no status", ciCombinedState([]string{"success", "no status"}))
+	assert.Equal(t, "pending", ciCombinedState([]string{"no status", "pending", "success"}))
+	assert.Equal(t, "failure", ciCombinedState([]string{"pending", "failure", "error"}))
+}
+
+func TestFormatCiStatusTable(t *testing.T) {
+	commits := []ciCommit{
+		{Name: "master", Sha: "a1b2c3d4e5f6", State: "success", Contexts: []ciContext{{State: "success", Result: "success", Name: "travis"}}},
+		{Name: "feature", Sha: "f6e5d4c3b2a1", State: "no status"},
+	}
+
+	expected := "master   a1b2c3d  success\n" +
+		"feature  f6e5d4c  no status\n"
+	assert.Equal(t, expected, formatCiStatusTable(commits, false))
+
+	expected = "master   a1b2c3d  success\n" +
+		"    success  travis\n" +
+		"feature  f6e5d4c  no status\n"
+	assert.Equal(t, expected, formatCiStatusTable(commits, true))
+}
+
+func TestFormatCiStatusJSON(t *testing.T) {
+	createdAt := time.Date(2014, 3, 1, 12, 0, 0, 0, time.UTC)
+	commit := ciCommit{
+		Name:  "HEAD",
+		Sha:   "a1b2c3d4e5f6",
+		State: "success",
+		Contexts: []ciContext{
+			{State: "success", Result: "success", Name: "travis", TargetURL: "https://travis-ci.org/jingweno/gh/builds/1", CreatedAt: &createdAt},
+		},
+	}
+
+	expected := `{
+  "ref": "HEAD",
+  "sha": "a1b2c3d4e5f6",
+  "state": "success",
+  "contexts": [
+    {
+      "state": "success",
+      "result": "success",
+      "name": "travis",
+      "description": "",
+      "target_url": "https://travis-ci.org/jingweno/gh/builds/1",
+      "created_at": "2014-03-01T12:00:00Z"
+    }
+  ]
+}
+`
+	out, err := formatCiStatusJSON(commit)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, expected, out)
+}
+
+func TestWatchCiStatus(t *testing.T) {
+	polls := 0
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/repos/jingweno/gh/commits/a1b2c3d/status", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		w.Header().Set("Content-Type", "application/json")
+		switch polls {
+		case 1:
+			fmt.Fprint(w, `{"state": "pending", "statuses": []}`)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, `{"message": "Bad Gateway"}`)
+		default:
+			fmt.Fprint(w, `{"state": "success", "statuses": [{"state": "success", "context": "ci"}]}`)
+		}
+	})
+	mux.HandleFunc("/repos/jingweno/gh/commits/a1b2c3d/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"check_runs": []}`)
+	})
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+	github.CreateTestConfigs("jingweno", "123")
+
+	interval, timeout := flagCiStatusInterval, flagCiStatusTimeout
+	flagCiStatusInterval, flagCiStatusTimeout = time.Millisecond, time.Minute
+	defer func() { flagCiStatusInterval, flagCiStatusTimeout = interval, timeout }()
+
+	project := &github.Project{Owner: "jingweno", Name: "gh", Host: "github.com"}
+	exitCode := watchCiStatus(ciCommit{Name: "HEAD", Sha: "a1b2c3d", Project: project})
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, 3, polls)
+}
+
+func TestResolveCiCommit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/jingweno/gh/pulls/123", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"number": 123, "head": {"sha": "a1b2c3d"}}`)
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+	github.CreateTestConfigs("jingweno", "123")
+
+	project := &github.Project{Owner: "jingweno", Name: "gh", Host: "github.com"}
+
+	commit, err := resolveCiCommit(project, "#123")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a1b2c3d", commit.Sha)
+
+	commit, err = resolveCiCommit(project, "123")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a1b2c3d", commit.Sha)
+
+	commit, err = resolveCiCommit(project, "HEAD")
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, "a1b2c3d", commit.Sha)
+
+	_, err = resolveCiCommit(project, "no-such-branch")
+	assert.Equal(t, "Aborted: no revision could be determined from 'no-such-branch'", err.Error())
+}
+
+func TestFetchCiStatusWithoutChecksAPI(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/repos/jingweno/gh/commits/a1b2c3d/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"state": "success", "statuses": [{"state": "success", "context": "ci"}]}`)
+	})
+	mux.HandleFunc("/repos/jingweno/gh/commits/a1b2c3d/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Not Found"}`)
+	})
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+	github.CreateTestConfigs("jingweno", "123")
+
+	project := &github.Project{Owner: "jingweno", Name: "gh", Host: "github.com"}
+	state, contexts, exitCode, err := fetchCiStatus(project, "a1b2c3d", false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "success", state)
+	assert.Equal(t, 1, len(contexts))
+	assert.Equal(t, 0, exitCode)
+}
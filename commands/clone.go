@@ -1,7 +1,14 @@
 package commands
 
 import (
+	"fmt"
+	"github.com/jingweno/gh/cmd"
 	"github.com/jingweno/gh/github"
+	"github.com/jingweno/gh/utils"
+	flag "github.com/ogier/pflag"
+	"os"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
 )
@@ -15,6 +22,16 @@ var cmdClone = &Command{
 DIRECTORY as with git-clone(1). When USER/ is omitted, assumes
 your GitHub login. With -p, clone private repositories over SSH.
 For repositories under your GitHub login, -p is implicit.
+
+With --all, every repository of the user or organization OWNER is cloned into
+DIR, the current directory by default, or fetched when it has been cloned
+already. A repository is reported as failed when a directory of its name isn't
+a git repository. Private repositories are always cloned over SSH. Only the
+repositories whose names match the --filter GLOB are cloned, and archived
+repositories are skipped with --archived=false. Up to N repositories are
+cloned at the same time with --parallel, 4 by default:
+
+    clone --all [-p] [--filter=<GLOB>] [--archived=false] [--parallel=<N>] [-d <DIR>] OWNER
 `,
 }
 
@@ -36,11 +53,184 @@ func init() {
   > git clone git@github.com:YOUR_LOGIN/jekyll_and_hyde.git
 */
 func clone(command *Command, args *Args) {
-	if !args.IsParamsEmpty() {
+	if args.IndexOfParam("--all") != -1 {
+		cloneAll(args)
+	} else if !args.IsParamsEmpty() {
 		transformCloneArgs(args)
 	}
 }
 
+/**
+  $ gh clone --all sinatra
+  > git clone git://github.com/sinatra/sinatra.git sinatra
+  > git clone git://github.com/sinatra/mustermann.git mustermann
+
+  $ gh clone --all -p --filter=sinatra-* --archived=false -d ~/src sinatra
+  > git clone git@github.com:sinatra/sinatra-contrib.git ~/src/sinatra-contrib
+  > git -C ~/src/sinatra-recipes fetch
+*/
+func cloneAll(args *Args) {
+	var (
+		all, isSSH, archived bool
+		filter, dir          string
+		parallel             int
+	)
+
+	flags := flag.NewFlagSet("clone --all", flag.ContinueOnError)
+	flags.BoolVar(&all, "all", false, "ALL")
+	flags.BoolVarP(&isSSH, "private", "p", false, "PRIVATE")
+	flags.BoolVar(&archived, "archived", true, "ARCHIVED")
+	flags.StringVar(&filter, "filter", "", "GLOB")
+	flags.StringVarP(&dir, "directory", "d", "", "DIR")
+	flags.IntVar(&parallel, "parallel", 4, "N")
+
+	usage := "usage: clone --all [-p] [--filter=<GLOB>] [--archived=false] [--parallel=<N>] [-d <DIR>] OWNER"
+	err := flags.Parse(args.Params)
+	if err == nil && flags.NArg() != 1 {
+		err = fmt.Errorf("Missing OWNER")
+	}
+	if err != nil {
+		utils.Check(fmt.Errorf("%s\n%s", err, usage))
+	}
+
+	owner := flags.Arg(0)
+	gh := github.NewClient(github.DefaultHost())
+	repos, err := gh.OwnerRepositories(owner)
+	utils.Check(err)
+
+	repos, err = selectCloneRepositories(repos, filter, archived)
+	utils.Check(err)
+
+	if len(repos) == 0 {
+		fmt.Printf("No repositories of %s to clone\n", owner)
+		os.Exit(0)
+	}
+
+	var (
+		commands   [][]string
+		cloneRepos []github.RepositorySummary
+		failed     int
+	)
+	for _, repo := range repos {
+		command, err := cloneAllCommand(repo, dir, isSSH)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", repo.FullName, err)
+			failed++
+			continue
+		}
+		commands = append(commands, command)
+		cloneRepos = append(cloneRepos, repo)
+	}
+
+	if args.Noop && len(commands) > 0 {
+		args.Replace(commands[0][0], commands[0][1], commands[0][2:]...)
+		for _, c := range commands[1:] {
+			args.After(c...)
+		}
+		return
+	}
+
+	if !args.Noop {
+		failed += runCloneAllCommands(cloneRepos, commands, parallel)
+	}
+	if failed > 0 {
+		utils.Check(fmt.Errorf("Failed to clone or fetch %d of %d repositories", failed, len(repos)))
+	}
+
+	os.Exit(0)
+}
+
+// The filter is matched against the name of a repository without its owner.
+func selectCloneRepositories(repos []github.RepositorySummary, filter string, archived bool) (selected []github.RepositorySummary, err error) {
+	for _, repo := range repos {
+		if repo.Archived && !archived {
+			continue
+		}
+
+		if filter != "" {
+			matched, e := path.Match(filter, repo.Name)
+			if e != nil {
+				err = fmt.Errorf("Invalid filter: %s", filter)
+				return
+			}
+			if !matched {
+				continue
+			}
+		}
+
+		selected = append(selected, repo)
+	}
+
+	return
+}
+
+// A repository that has been cloned already is fetched instead, while a
+// directory of the same name that isn't a git repository is left alone. The
+// clone URL is worked out by transformCloneArgs, the same way as for a single
+// repository.
+func cloneAllCommand(repo github.RepositorySummary, dir string, isSSH bool) ([]string, error) {
+	repoDir := filepath.Join(dir, repo.Name)
+	if isDir(repoDir) {
+		if _, err := os.Stat(filepath.Join(repoDir, ".git")); err != nil {
+			return nil, fmt.Errorf("%s already exists and isn't a git repository", repoDir)
+		}
+		return []string{"git", "-C", repoDir, "fetch"}, nil
+	}
+
+	params := []string{"clone"}
+	if isSSH || repo.Private {
+		params = append(params, "-p")
+	}
+	params = append(params, repo.FullName, repoDir)
+
+	cloneArgs := NewArgs(params)
+	transformCloneArgs(cloneArgs)
+
+	return append([]string{"git", "clone"}, cloneArgs.Params...), nil
+}
+
+func runCloneAllCommands(repos []github.RepositorySummary, commands [][]string, parallel int) (failed int) {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	type cloneResult struct {
+		Repo   github.RepositorySummary
+		Output string
+		Err    error
+	}
+
+	jobs := make(chan int)
+	results := make(chan cloneResult)
+	for i := 0; i < parallel; i++ {
+		go func() {
+			for j := range jobs {
+				output, err := cmd.NewWithArray(commands[j]).ExecOutput()
+				results <- cloneResult{Repo: repos[j], Output: output, Err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for j := range commands {
+			jobs <- j
+		}
+		close(jobs)
+	}()
+
+	for _ = range commands {
+		result := <-results
+		if result.Err == nil {
+			fmt.Printf("%s\n", result.Repo.FullName)
+		} else {
+			failed++
+			fmt.Fprintf(os.Stderr, "%s: %s\n%s", result.Repo.FullName, result.Err, result.Output)
+		}
+	}
+
+	return
+}
+
 func transformCloneArgs(args *Args) {
 	isSSH := parseClonePrivateFlag(args)
 	hasValueRegxp := regexp.MustCompile("^(--(upload-pack|template|depth|origin|branch|reference|name)|-[ubo])$")
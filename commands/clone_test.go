@@ -4,6 +4,7 @@ import (
 	"github.com/bmizerany/assert"
 	"github.com/jingweno/gh/github"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -54,3 +55,54 @@ func TestTransformCloneArgs(t *testing.T) {
 	assert.Equal(t, "git://github.com/jingweno/gh", args.FirstParam())
 	assert.Equal(t, "gh", args.GetParam(1))
 }
+
+func TestSelectCloneRepositories(t *testing.T) {
+	repos := []github.RepositorySummary{
+		{Name: "sinatra", FullName: "sinatra/sinatra"},
+		{Name: "sinatra-contrib", FullName: "sinatra/sinatra-contrib"},
+		{Name: "sinatra-recipes", FullName: "sinatra/sinatra-recipes", Archived: true},
+		{Name: "mustermann", FullName: "sinatra/mustermann"},
+	}
+
+	selected, err := selectCloneRepositories(repos, "", true)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 4, len(selected))
+
+	selected, err = selectCloneRepositories(repos, "sinatra-*", false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(selected))
+	assert.Equal(t, "sinatra-contrib", selected[0].Name)
+
+	_, err = selectCloneRepositories(repos, "[", true)
+	assert.Equal(t, "Invalid filter: [", err.Error())
+}
+
+func TestCloneAllCommand(t *testing.T) {
+	os.Setenv("GH_PROTOCOL", "git")
+	github.CreateTestConfigs("jingweno", "123")
+
+	repo := github.RepositorySummary{Name: "sinatra", FullName: "sinatra/sinatra"}
+	command, err := cloneAllCommand(repo, "src", false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"git", "clone", "git://github.com/sinatra/sinatra.git", "src/sinatra"}, command)
+	command, _ = cloneAllCommand(repo, "", true)
+	assert.Equal(t, []string{"git", "clone", "git@github.com:sinatra/sinatra.git", "sinatra"}, command)
+
+	repo = github.RepositorySummary{Name: "secret", FullName: "sinatra/secret", Private: true}
+	command, _ = cloneAllCommand(repo, "", false)
+	assert.Equal(t, []string{"git", "clone", "git@github.com:sinatra/secret.git", "secret"}, command)
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+	repoDir := filepath.Join(dir, "sinatra")
+	os.Mkdir(repoDir, 0755)
+
+	repo = github.RepositorySummary{Name: "sinatra", FullName: "sinatra/sinatra"}
+	_, err = cloneAllCommand(repo, dir, false)
+	assert.Equal(t, repoDir+" already exists and isn't a git repository", err.Error())
+
+	os.Mkdir(filepath.Join(repoDir, ".git"), 0755)
+	command, err = cloneAllCommand(repo, dir, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"git", "-C", repoDir, "fetch"}, command)
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jingweno/gh/github"
+	"github.com/jingweno/gh/utils"
+)
+
+var (
+	cmdCollaborator = &Command{
+		Run:   collaborator,
+		Usage: "collaborator <COMMAND> [<OPTIONS>]",
+		Short: "Manage the collaborators of a repository in GitHub",
+		Long: `Manages the collaborators of the project that the "origin" remote points to.
+See the usage of each <COMMAND> for its <OPTIONS>.
+`}
+
+	cmdListCollaborator = &Command{
+		Key:   "list",
+		Run:   listCollaborator,
+		Usage: "collaborator list",
+		Short: "List the collaborators of a repository",
+		Long: `Lists the login and the highest permission of each collaborator.
+`}
+
+	cmdAddCollaborator = &Command{
+		Key:   "add",
+		Run:   addCollaborator,
+		Usage: "collaborator add [--permission=<PERMISSION>] <USER>",
+		Short: "Add a collaborator to a repository",
+		Long: `Invites <USER> to collaborate on the repository with <PERMISSION>, one of
+"pull", "push" or "admin", "push" by default. The permission of an existing
+collaborator is changed instead.
+`}
+
+	cmdRemoveCollaborator = &Command{
+		Key:   "remove",
+		Run:   removeCollaborator,
+		Usage: "collaborator remove <USER>",
+		Short: "Remove a collaborator from a repository",
+		Long: `Removes <USER> from the collaborators of the repository.
+`}
+)
+
+var (
+	flagCollaboratorPermission string
+
+	collaboratorPermissions = []string{"pull", "push", "admin"}
+)
+
+func init() {
+	cmdAddCollaborator.Flag.StringVar(&flagCollaboratorPermission, "permission", "push", "PERMISSION")
+
+	cmdCollaborator.Use(cmdListCollaborator)
+	cmdCollaborator.Use(cmdAddCollaborator)
+	cmdCollaborator.Use(cmdRemoveCollaborator)
+	CmdRunner.Use(cmdCollaborator)
+}
+
+func collaborator(cmd *Command, args *Args) {
+	utils.Check(fmt.Errorf("Missed subcommand\n%s", cmd.subCommandsUsage()))
+}
+
+/*
+  $ gh collaborator list
+  > jingweno  admin
+  > octocat   push
+*/
+func listCollaborator(cmd *Command, args *Args) {
+	runInLocalRepo(func(localRepo *github.GitHubRepo, project *github.Project, gh *github.Client) {
+		if args.Noop {
+			fmt.Printf("Would request list of collaborators for %s\n", project)
+			return
+		}
+
+		collaborators, err := gh.Collaborators(project)
+		utils.Check(err)
+
+		fmt.Print(formatCollaborators(collaborators))
+	})
+}
+
+/*
+  $ gh collaborator add --permission=admin octocat
+  > Invited octocat to jingweno/gh with admin permission
+*/
+func addCollaborator(cmd *Command, args *Args) {
+	if args.ParamsSize() != 1 {
+		utils.Check(fmt.Errorf("usage: %s", cmd.FormattedUsage()))
+	}
+	utils.Check(checkPermission(flagCollaboratorPermission))
+
+	user := args.FirstParam()
+	runInLocalRepo(func(localRepo *github.GitHubRepo, project *github.Project, gh *github.Client) {
+		if args.Noop {
+			fmt.Printf("Would add %s to %s with %s permission\n", user, project, flagCollaboratorPermission)
+			return
+		}
+
+		invited, err := gh.AddCollaborator(project, user, flagCollaboratorPermission)
+		utils.Check(err)
+
+		if invited {
+			fmt.Printf("Invited %s to %s with %s permission\n", user, project, flagCollaboratorPermission)
+		} else {
+			fmt.Printf("Gave %s %s permission on %s\n", user, flagCollaboratorPermission, project)
+		}
+	})
+}
+
+/*
+  $ gh collaborator remove octocat
+  > Removed octocat from jingweno/gh
+*/
+func removeCollaborator(cmd *Command, args *Args) {
+	if args.ParamsSize() != 1 {
+		utils.Check(fmt.Errorf("usage: %s", cmd.FormattedUsage()))
+	}
+
+	user := args.FirstParam()
+	runInLocalRepo(func(localRepo *github.GitHubRepo, project *github.Project, gh *github.Client) {
+		if args.Noop {
+			fmt.Printf("Would remove %s from %s\n", user, project)
+			return
+		}
+
+		utils.Check(gh.RemoveCollaborator(project, user))
+
+		fmt.Printf("Removed %s from %s\n", user, project)
+	})
+}
+
+func checkPermission(permission string) error {
+	for _, p := range collaboratorPermissions {
+		if p == permission {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Unknown permission: %s (use %s)", permission, strings.Join(collaboratorPermissions, ", "))
+}
+
+// The logins are padded to line up the permissions.
+func formatCollaborators(collaborators []github.Collaborator) string {
+	width := 0
+	for _, c := range collaborators {
+		if len(c.Login) > width {
+			width = len(c.Login)
+		}
+	}
+
+	var out string
+	for _, c := range collaborators {
+		out += fmt.Sprintf("%-*s  %s\n", width, c.Login, c.Permission())
+	}
+
+	return out
+}
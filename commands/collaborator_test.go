@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+	"github.com/jingweno/gh/github"
+)
+
+func TestCheckPermission(t *testing.T) {
+	assert.Equal(t, nil, checkPermission("push"))
+
+	err := checkPermission("write")
+	assert.Equal(t, "Unknown permission: write (use pull, push, admin)", err.Error())
+}
+
+func TestFormatCollaborators(t *testing.T) {
+	var admin, writer, reader github.Collaborator
+	admin.Login = "jingweno"
+	admin.Permissions.Admin, admin.Permissions.Push, admin.Permissions.Pull = true, true, true
+	writer.Login = "octocat"
+	writer.Permissions.Push, writer.Permissions.Pull = true, true
+	reader.Login = "hubot"
+	reader.Permissions.Pull = true
+
+	expected := `jingweno  admin
+octocat   push
+hubot     pull
+`
+	assert.Equal(t, expected, formatCollaborators([]github.Collaborator{admin, writer, reader}))
+}
@@ -24,6 +24,10 @@ type Command struct {
 	Short        string
 	Long         string
 	GitExtension bool
+	// Params that don't name a subcommand are passed to the command itself
+	AcceptsParams bool
+	// Values of the flags that can be given without a value
+	OptionalFlagValues map[string][]string
 
 	subCommands map[string]*Command
 }
@@ -54,13 +58,42 @@ func (c *Command) parseArguments(args *Args) (err error) {
 		c.Flag.Usage = c.PrintUsage
 	}
 
-	if err = c.Flag.Parse(args.Params); err == nil {
+	params := expandOptionalFlagValues(args.Params, c.OptionalFlagValues)
+	if err = c.Flag.Parse(params); err == nil {
 		args.Params = c.Flag.Args()
 	}
 
 	return
 }
 
+// Long flags only take a value after "=", so a flag with an optional value
+// given alone, as "--checksums", is given its first value, as
+// "--checksums=sha256". Any of its values can also follow it as the next
+// word, as "--checksums sha512", which isn't left behind as a parameter.
+func expandOptionalFlagValues(params []string, values map[string][]string) (expanded []string) {
+	for i := 0; i < len(params); i++ {
+		p := params[i]
+		if p == "--" {
+			return append(expanded, params[i:]...)
+		}
+
+		if flagValues, ok := values[strings.TrimPrefix(p, "--")]; ok && strings.HasPrefix(p, "--") {
+			value := flagValues[0]
+			for _, v := range flagValues {
+				if i+1 < len(params) && params[i+1] == v {
+					i++
+					value = v
+					break
+				}
+			}
+			p = fmt.Sprintf("%s=%s", p, value)
+		}
+		expanded = append(expanded, p)
+	}
+
+	return
+}
+
 func (c *Command) Use(subCommand *Command) {
 	if c.subCommands == nil {
 		c.subCommands = make(map[string]*Command)
@@ -121,6 +154,8 @@ func lookupCommand(c *Command, args *Args) (runCommand *Command, err error) {
 		if subCommand, ok := c.subCommands[subCommandName]; ok {
 			runCommand = subCommand
 			args.Params = args.Params[1:]
+		} else if c.AcceptsParams {
+			runCommand = c
 		} else {
 			err = fmt.Errorf("error: Unknown subcommand: %s\n%s", subCommandName, c.subCommandsUsage())
 		}
@@ -41,6 +41,20 @@ func TestCommandUseErrorWhenMissingSubcommand(t *testing.T) {
 	assert.NotEqual(t, nil, err)
 }
 
+func TestCommandUseSelfWhenAcceptingParams(t *testing.T) {
+	c := &Command{Usage: "foo", AcceptsParams: true}
+	s := &Command{Usage: "bar"}
+	c.Use(s)
+
+	args := NewArgs([]string{"foo", "baz"})
+
+	run, err := lookupCommand(c, args)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, c, run)
+	assert.Equal(t, []string{"baz"}, args.Params)
+}
+
 func TestArgsForCommand(t *testing.T) {
 	c := &Command{Usage: "foo"}
 
@@ -78,6 +92,36 @@ func TestFlagsAfterArguments(t *testing.T) {
 	assert.Equal(t, "bar", args.LastParam())
 }
 
+func TestFlagsWithOptionalValue(t *testing.T) {
+	c := &Command{Usage: "foo [--checksums[=ALGORITHM]] ARG1", OptionalFlagValues: map[string][]string{"checksums": {"sha256", "sha512"}}}
+
+	var flag string
+	c.Flag.StringVar(&flag, "checksums", "", "ALGORITHM")
+
+	args := NewArgs([]string{"foo", "--checksums", "bar"})
+	c.parseArguments(args)
+	assert.Equal(t, "sha256", flag)
+	assert.Equal(t, []string{"bar"}, args.Params)
+
+	args = NewArgs([]string{"foo", "--checksums=sha512", "bar"})
+	c.parseArguments(args)
+	assert.Equal(t, "sha512", flag)
+
+	args = NewArgs([]string{"foo", "--checksums", "sha512", "bar"})
+	c.parseArguments(args)
+	assert.Equal(t, "sha512", flag)
+	assert.Equal(t, []string{"bar"}, args.Params)
+
+	args = NewArgs([]string{"foo", "bar", "--checksums", "sha256"})
+	c.parseArguments(args)
+	assert.Equal(t, "sha256", flag)
+	assert.Equal(t, []string{"bar"}, args.Params)
+
+	args = NewArgs([]string{"foo", "--", "--checksums"})
+	c.parseArguments(args)
+	assert.Equal(t, []string{"--checksums"}, args.Params)
+}
+
 func TestCommandUsageSubCommands(t *testing.T) {
 	f1 := func(c *Command, args *Args) {}
 	f2 := func(c *Command, args *Args) {}
@@ -5,13 +5,14 @@ import (
 	"github.com/jingweno/gh/git"
 	"github.com/jingweno/gh/github"
 	"github.com/jingweno/gh/utils"
+	"github.com/jingweno/go-octokit/octokit"
 	"regexp"
 	"strings"
 )
 
 var cmdCreate = &Command{
 	Run:   create,
-	Usage: "create [-p] [-d DESCRIPTION] [-h HOMEPAGE] [NAME]",
+	Usage: "create [-p] [-d DESCRIPTION] [-h HOMEPAGE] [--gitignore=TEMPLATE] [--license=KEY] [--auto-init] [--team=TEAM] [--disable-issues] [--disable-wiki] [--template=OWNER/REPO] [NAME]",
 	Short: "Create this repository on GitHub and add GitHub as origin",
 	Long: `Create a new public GitHub repository from the current git
 repository and add remote origin at "git@github.com:USER/REPOSITORY.git";
@@ -20,18 +21,44 @@ directory name. To explicitly name the new repository, pass in NAME,
 optionally in ORGANIZATION/NAME form to create under an organization
 you're a member of. With -p, create a private repository, and with
 -d and -h set the repository's description and homepage URL, respectively.
+
+With --auto-init, the repository is created with an initial commit containing
+a README. --gitignore and --license add a .gitignore from TEMPLATE, such as
+"Go", and a LICENSE for KEY, such as "mit", to the initial commit. With --team,
+TEAM of the organization is given access to the new repository. Issues and the
+wiki are disabled with --disable-issues and --disable-wiki.
+
+With --template, the repository is generated from the files of the template
+repository OWNER/REPO, which can't be combined with the options above other
+than -p and -d.
 `,
 }
 
 var (
-	flagCreatePrivate                         bool
-	flagCreateDescription, flagCreateHomepage string
+	flagCreatePrivate,
+	flagCreateAutoInit,
+	flagCreateDisableIssues,
+	flagCreateDisableWiki bool
+
+	flagCreateDescription,
+	flagCreateHomepage,
+	flagCreateGitignore,
+	flagCreateLicense,
+	flagCreateTeam,
+	flagCreateTemplate string
 )
 
 func init() {
 	cmdCreate.Flag.BoolVarP(&flagCreatePrivate, "private", "p", false, "PRIVATE")
 	cmdCreate.Flag.StringVarP(&flagCreateDescription, "description", "d", "", "DESCRIPTION")
 	cmdCreate.Flag.StringVarP(&flagCreateHomepage, "homepage", "h", "", "HOMEPAGE")
+	cmdCreate.Flag.StringVar(&flagCreateGitignore, "gitignore", "", "TEMPLATE")
+	cmdCreate.Flag.StringVar(&flagCreateLicense, "license", "", "KEY")
+	cmdCreate.Flag.BoolVar(&flagCreateAutoInit, "auto-init", false, "AUTO_INIT")
+	cmdCreate.Flag.StringVar(&flagCreateTeam, "team", "", "TEAM")
+	cmdCreate.Flag.BoolVar(&flagCreateDisableIssues, "disable-issues", false, "DISABLE_ISSUES")
+	cmdCreate.Flag.BoolVar(&flagCreateDisableWiki, "disable-wiki", false, "DISABLE_WIKI")
+	cmdCreate.Flag.StringVar(&flagCreateTemplate, "template", "", "TEMPLATE")
 
 	CmdRunner.Use(cmdCreate)
 }
@@ -51,6 +78,14 @@ func init() {
   $ gh create sinatra/recipes
   [ repo created in GitHub organization ]
   > git remote add origin git@github.com:sinatra/recipes.git
+
+  $ gh create --license=mit --gitignore=Go --team=core sinatra/recipes
+  [ repo created in GitHub organization with a license, a .gitignore and team access ]
+  > git remote add origin git@github.com:sinatra/recipes.git
+
+  $ gh create --template=sinatra/template recipes
+  [ repo generated from the template repository ]
+  > git remote add origin git@github.com:YOUR_USER/recipes.git
 */
 func create(command *Command, args *Args) {
 	_, err := git.Dir()
@@ -85,6 +120,9 @@ func create(command *Command, args *Args) {
 	project := github.NewProject(owner, newRepoName, credentials.Host)
 	gh := github.NewClient(project.Host)
 
+	err = validateCreateFlags(project.Owner != credentials.User)
+	utils.Check(err)
+
 	var action string
 	if gh.IsRepositoryExist(project) {
 		fmt.Printf("%s already exists on %s\n", project, project.Host)
@@ -92,7 +130,7 @@ func create(command *Command, args *Args) {
 	} else {
 		action = "created repository"
 		if !args.Noop {
-			repo, err := gh.CreateRepository(project, flagCreateDescription, flagCreateHomepage, flagCreatePrivate)
+			repo, err := createRepository(gh, project)
 			utils.Check(err)
 			project = github.NewProject(repo.FullName, "", project.Host)
 		}
@@ -108,3 +146,57 @@ func create(command *Command, args *Args) {
 
 	args.After("echo", fmt.Sprintf("%s:", action), project.String())
 }
+
+func validateCreateFlags(isOrg bool) error {
+	if flagCreateTeam != "" && !isOrg {
+		return fmt.Errorf("--team only works for repositories of an organization")
+	}
+
+	if flagCreateTemplate != "" {
+		if flagCreateHomepage != "" || flagCreateGitignore != "" || flagCreateLicense != "" ||
+			flagCreateAutoInit || flagCreateTeam != "" || flagCreateDisableIssues || flagCreateDisableWiki {
+			return fmt.Errorf("--template can only be combined with -p and -d")
+		}
+
+		if !regexp.MustCompile(NameWithOwnerRe).MatchString(flagCreateTemplate) || !strings.Contains(flagCreateTemplate, "/") {
+			return fmt.Errorf("invalid template repository: %s (use OWNER/REPO)", flagCreateTemplate)
+		}
+	}
+
+	return nil
+}
+
+func createRepository(gh *github.Client, project *github.Project) (*octokit.Repository, error) {
+	params := github.RepositoryParams{
+		Description:       flagCreateDescription,
+		Homepage:          flagCreateHomepage,
+		Private:           flagCreatePrivate,
+		AutoInit:          flagCreateAutoInit,
+		GitignoreTemplate: flagCreateGitignore,
+		LicenseTemplate:   flagCreateLicense,
+	}
+
+	if flagCreateTemplate != "" {
+		template := github.NewProject("", flagCreateTemplate, project.Host)
+		return gh.GenerateRepository(template, project, params)
+	}
+
+	if flagCreateDisableIssues {
+		hasIssues := false
+		params.HasIssues = &hasIssues
+	}
+	if flagCreateDisableWiki {
+		hasWiki := false
+		params.HasWiki = &hasWiki
+	}
+
+	if flagCreateTeam != "" {
+		team, err := gh.Team(project.Owner, flagCreateTeam)
+		if err != nil {
+			return nil, err
+		}
+		params.TeamID = team.ID
+	}
+
+	return gh.CreateRepository(project, params)
+}
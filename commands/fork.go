@@ -6,21 +6,44 @@ import (
 	"github.com/jingweno/gh/utils"
 	"os"
 	"reflect"
+	"regexp"
+	"strings"
+	"time"
 )
 
 var cmdFork = &Command{
 	Run:   fork,
-	Usage: "fork [--no-remote]",
+	Usage: "fork [--no-remote] [--org=<ORG>] [--remote-name=<NAME>] [<OWNER>/<REPO> [--clone]]",
 	Short: "Make a fork of a remote repository on GitHub and add as remote",
 	Long: `Forks the original project (referenced by "origin" remote) on GitHub and
-adds a new remote for it under your username.
+adds a new remote for it under your username, or under the name given with
+--remote-name.
+
+With <OWNER>/<REPO>, it forks that repository instead and doesn't need to be
+run inside a clone. With --clone, the fork is then cloned into a directory
+named after the repository, with the "upstream" remote pointing to the parent.
+
+With --org, the repository is forked into the organization <ORG> instead of
+your user.
 `,
 }
 
-var flagForkNoRemote bool
+var (
+	flagForkNoRemote,
+	flagForkClone bool
+
+	flagForkOrg,
+	flagForkRemoteName string
+
+	forkPollInterval = 2 * time.Second
+	forkTimeout      = 5 * time.Minute
+)
 
 func init() {
 	cmdFork.Flag.BoolVar(&flagForkNoRemote, "no-remote", false, "")
+	cmdFork.Flag.BoolVar(&flagForkClone, "clone", false, "")
+	cmdFork.Flag.StringVar(&flagForkOrg, "org", "", "ORG")
+	cmdFork.Flag.StringVar(&flagForkRemoteName, "remote-name", "", "NAME")
 
 	CmdRunner.Use(cmdFork)
 }
@@ -32,16 +55,48 @@ func init() {
 
   $ gh fork --no-remote
   [ repo forked on GitHub ]
+
+  $ gh fork --org=sinatra --remote-name=fork
+  [ repo forked into the sinatra organization on GitHub ]
+  > git remote add -f fork git@github.com:sinatra/CURRENT_REPO.git
+
+  $ gh fork jingweno/gh
+  [ repo forked on GitHub ]
+  > new fork: YOUR_USER/gh
+
+  $ gh fork jingweno/gh --clone
+  [ repo forked on GitHub ]
+  > git clone -o origin git@github.com:YOUR_USER/gh.git gh
+  > git -C gh remote add -f upstream git://github.com/jingweno/gh.git
 */
 func fork(cmd *Command, args *Args) {
-	localRepo := github.LocalRepo()
+	var project *github.Project
+	isNamed := !args.IsParamsEmpty()
+	if isNamed {
+		name := args.FirstParam()
+		if !regexp.MustCompile(NameWithOwnerRe).MatchString(name) || !strings.Contains(name, "/") {
+			utils.Check(fmt.Errorf("Invalid repository: %s (use <OWNER>/<REPO>)", name))
+		}
+		project = github.NewProject(name, "", "")
+	} else {
+		if flagForkClone {
+			utils.Check(fmt.Errorf("Can't clone a fork without <OWNER>/<REPO>\nusage: %s", cmd.FormattedUsage()))
+		}
+
+		localRepo := github.LocalRepo()
 
-	project, err := localRepo.MainProject()
-	utils.Check(err)
+		var err error
+		project, err = localRepo.MainProject()
+		utils.Check(err)
+	}
 
 	configs := github.CurrentConfigs()
 	credentials := configs.PromptFor(project.Host)
-	forkProject := github.NewProject(credentials.User, project.Name, project.Host)
+	forkOwner := credentials.User
+	if flagForkOrg != "" {
+		forkOwner = flagForkOrg
+	}
+	forkProject := github.NewProject(forkOwner, project.Name, project.Host)
 
 	client := github.NewClient(project.Host)
 	existingRepo, err := client.Repository(forkProject)
@@ -57,16 +112,54 @@ func fork(cmd *Command, args *Args) {
 		}
 	} else {
 		if !args.Noop {
-			_, err := client.ForkRepository(project)
+			_, err := client.ForkRepository(project, flagForkOrg)
+			utils.Check(err)
+
+			err = waitForRepository(client, forkProject, forkTimeout)
 			utils.Check(err)
 		}
 	}
 
-	if flagForkNoRemote {
+	if flagForkClone {
+		remoteName := flagForkRemoteName
+		if remoteName == "" {
+			remoteName = "origin"
+		}
+
+		dir := project.Name
+		args.Replace("git", "clone", "-o", remoteName, forkProject.GitURL("", "", true), dir)
+		args.After("git", "-C", dir, "remote", "add", "-f", "upstream", project.GitURL("", "", false))
+	} else if flagForkNoRemote {
+		os.Exit(0)
+	} else if isNamed {
+		fmt.Printf("new fork: %s\n", forkProject)
 		os.Exit(0)
 	} else {
+		remoteName := flagForkRemoteName
+		if remoteName == "" {
+			remoteName = forkProject.Owner
+		}
+
 		u := forkProject.GitURL("", "", true)
-		args.Replace("git", "remote", "add", "-f", forkProject.Owner, u)
-		args.After("echo", fmt.Sprintf("new remote: %s", forkProject.Owner))
+		args.Replace("git", "remote", "add", "-f", remoteName, u)
+		args.After("echo", fmt.Sprintf("new remote: %s", remoteName))
+	}
+}
+
+// GitHub creates forks asynchronously, so cloning or fetching right after
+// forking can fail until the repository shows up in the API.
+func waitForRepository(client *github.Client, project *github.Project, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := client.Repository(project)
+		if err == nil {
+			return nil
+		}
+
+		if time.Now().Add(forkPollInterval).After(deadline) {
+			return fmt.Errorf("Timed out after %s waiting for %s to be available", timeout, project)
+		}
+
+		time.Sleep(forkPollInterval)
 	}
 }
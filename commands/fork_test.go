@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	"github.com/jingweno/gh/github"
+)
+
+func TestWaitForRepository(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/octocat/gh", r.URL.Path)
+
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		if requests < 3 {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+			return
+		}
+		fmt.Fprint(w, `{"full_name":"octocat/gh"}`)
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	interval := forkPollInterval
+	forkPollInterval = time.Millisecond
+	defer func() { forkPollInterval = interval }()
+
+	gh := &github.Client{Credentials: &github.Credentials{Host: "github.com", AccessToken: "123"}}
+	project := &github.Project{Owner: "octocat", Name: "gh", Host: "github.com"}
+
+	err := waitForRepository(gh, project, time.Minute)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, requests)
+
+	requests = 0
+	err = waitForRepository(gh, project, 0)
+	assert.Equal(t, "Timed out after 0s waiting for octocat/gh to be available", err.Error())
+}
@@ -0,0 +1,328 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jingweno/gh/github"
+	"github.com/jingweno/gh/utils"
+)
+
+var (
+	cmdGist = &Command{
+		Run:   gist,
+		Usage: "gist <COMMAND> [<OPTIONS>]",
+		Short: "Manage gists in GitHub",
+		Long: `Manages the gists of your GitHub user. See the usage of each <COMMAND> for
+its <OPTIONS>.
+`}
+
+	cmdCreateGist = &Command{
+		Key:   "create",
+		Run:   createGist,
+		Usage: "gist create [-p] [-d <DESCRIPTION>] (<FILE>...|-)",
+		Short: "Create a gist in GitHub",
+		Long: `Creates a gist with the content of each <FILE>, or of the standard input
+with "-", and prints its URL. The gist is secret unless "-p" is given to make
+it public.
+`}
+
+	cmdListGist = &Command{
+		Key:   "list",
+		Run:   listGist,
+		Usage: "gist list",
+		Short: "List your gists in GitHub",
+		Long: `Lists the ID, visibility and description of your gists.
+`}
+
+	cmdViewGist = &Command{
+		Key:   "view",
+		Run:   viewGist,
+		Usage: "gist view <ID> [<FILE>]",
+		Short: "Show the content of a gist",
+		Long: `Prints the content of the files of the gist <ID>, or only of <FILE>.
+`}
+
+	cmdEditGist = &Command{
+		Key:   "edit",
+		Run:   editGist,
+		Usage: "gist edit <ID> [<FILE>]",
+		Short: "Edit a gist in a text editor",
+		Long: `Opens <FILE> of the gist <ID> in the text editor of git and saves it to the
+gist once the editor is closed. <FILE> can be omitted when the gist has a
+single file.
+`}
+
+	cmdCloneGist = &Command{
+		Key:   "clone",
+		Run:   cloneGist,
+		Usage: "gist clone <ID> [<DIRECTORY>]",
+		Short: "Clone a gist",
+		Long: `Clones the git repository of the gist <ID> into <DIRECTORY>, or into a
+directory named after <ID>.
+`}
+)
+
+var (
+	flagGistPublic bool
+
+	flagGistDescription string
+)
+
+func init() {
+	cmdCreateGist.Flag.BoolVarP(&flagGistPublic, "public", "p", false, "PUBLIC")
+	cmdCreateGist.Flag.StringVarP(&flagGistDescription, "description", "d", "", "DESCRIPTION")
+
+	cmdGist.Use(cmdCreateGist)
+	cmdGist.Use(cmdListGist)
+	cmdGist.Use(cmdViewGist)
+	cmdGist.Use(cmdEditGist)
+	cmdGist.Use(cmdCloneGist)
+	CmdRunner.Use(cmdGist)
+}
+
+func gist(cmd *Command, args *Args) {
+	utils.Check(fmt.Errorf("Missed subcommand\n%s", cmd.subCommandsUsage()))
+}
+
+/*
+  $ gh gist create -d "build log" build.log
+  > https://gist.github.com/8da7fb575debd88c54cf
+
+  $ make 2>&1 | gh gist create -p -
+  > https://gist.github.com/8da7fb575debd88c54cf
+*/
+func createGist(cmd *Command, args *Args) {
+	if args.IsParamsEmpty() {
+		utils.Check(fmt.Errorf("Missing <FILE> or -\nusage: %s", cmd.FormattedUsage()))
+	}
+
+	files, err := gistFiles(args.Params, os.Stdin)
+	utils.Check(err)
+
+	if args.Noop {
+		fmt.Printf("Would create a gist with %s\n", strings.Join(args.Params, ", "))
+		os.Exit(0)
+	}
+
+	params := github.Gist{Description: flagGistDescription, Public: flagGistPublic, Files: files}
+	gh := github.NewClient(github.DefaultHost())
+	gist, err := gh.CreateGist(params)
+	utils.Check(err)
+
+	fmt.Println(gist.HTMLURL)
+	os.Exit(0)
+}
+
+/*
+  $ gh gist list
+  > 8da7fb575debd88c54cf  secret  build log
+*/
+func listGist(cmd *Command, args *Args) {
+	if args.Noop {
+		fmt.Println("Would request list of gists")
+		os.Exit(0)
+	}
+
+	gh := github.NewClient(github.DefaultHost())
+	gists, err := gh.Gists()
+	utils.Check(err)
+
+	for _, gist := range gists {
+		fmt.Println(formatGist(gist))
+	}
+
+	os.Exit(0)
+}
+
+/*
+  $ gh gist view 8da7fb575debd88c54cf
+  > (prints the content of every file of the gist)
+
+  $ gh gist view 8da7fb575debd88c54cf build.log
+  > (prints the content of build.log)
+*/
+func viewGist(cmd *Command, args *Args) {
+	id, name := gistIDAndFile(cmd, args)
+
+	gh := github.NewClient(github.DefaultHost())
+	gist, err := gh.Gist(id)
+	utils.Check(err)
+
+	if name != "" {
+		file, ok := gist.Files[name]
+		if !ok {
+			utils.Check(fmt.Errorf("Gist %s has no file %s", id, name))
+		}
+		content, err := gh.GistFileContent(file)
+		utils.Check(err)
+
+		fmt.Print(content)
+		os.Exit(0)
+	}
+
+	names := gistFileNames(gist)
+	for i, name := range names {
+		if len(names) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("==> %s <==\n", name)
+		}
+
+		content, err := gh.GistFileContent(gist.Files[name])
+		utils.Check(err)
+
+		fmt.Print(content)
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			fmt.Println()
+		}
+	}
+
+	os.Exit(0)
+}
+
+/*
+  $ gh gist edit 8da7fb575debd88c54cf
+  [ opens the text editor with the content of the gist ]
+  > https://gist.github.com/8da7fb575debd88c54cf
+*/
+func editGist(cmd *Command, args *Args) {
+	id, name := gistIDAndFile(cmd, args)
+
+	gh := github.NewClient(github.DefaultHost())
+	gist, err := gh.Gist(id)
+	utils.Check(err)
+
+	if name == "" {
+		names := gistFileNames(gist)
+		if len(names) != 1 {
+			utils.Check(fmt.Errorf("Gist %s has several files, choose one of: %s", id, strings.Join(names, ", ")))
+		}
+		name = names[0]
+	}
+
+	file, ok := gist.Files[name]
+	if !ok {
+		utils.Check(fmt.Errorf("Gist %s has no file %s", id, name))
+	}
+
+	original, err := gh.GistFileContent(file)
+	utils.Check(err)
+
+	editor, err := github.NewEditor("GIST", original)
+	utils.Check(err)
+
+	content, err := editor.Edit()
+	utils.Check(err)
+
+	if string(content) == original {
+		fmt.Println("Gist not changed")
+		os.Exit(0)
+	}
+
+	if args.Noop {
+		fmt.Printf("Would edit %s of gist %s\n", name, id)
+		os.Exit(0)
+	}
+
+	params := github.Gist{Files: map[string]github.GistFile{name: github.GistFile{Content: string(content)}}}
+	gist, err = gh.EditGist(id, params)
+	utils.Check(err)
+
+	fmt.Println(gist.HTMLURL)
+	os.Exit(0)
+}
+
+/*
+  $ gh gist clone 8da7fb575debd88c54cf
+  > git clone https://gist.github.com/8da7fb575debd88c54cf.git 8da7fb575debd88c54cf
+*/
+func cloneGist(cmd *Command, args *Args) {
+	id, dir := gistIDAndFile(cmd, args)
+	if dir == "" {
+		dir = id
+	}
+
+	gh := github.NewClient(github.DefaultHost())
+	gist, err := gh.Gist(id)
+	utils.Check(err)
+
+	args.Replace("git", "clone", gist.GitPullURL, dir)
+}
+
+func gistIDAndFile(cmd *Command, args *Args) (id, file string) {
+	if args.IsParamsEmpty() || args.ParamsSize() > 2 {
+		utils.Check(fmt.Errorf("usage: %s", cmd.FormattedUsage()))
+	}
+
+	id = args.FirstParam()
+	if args.ParamsSize() == 2 {
+		file = args.GetParam(1)
+	}
+
+	return
+}
+
+// The standard input is read for "-", and named as GitHub names the files of
+// a gist without a name.
+func gistFiles(paths []string, stdin io.Reader) (files map[string]github.GistFile, err error) {
+	files = make(map[string]github.GistFile)
+	for _, path := range paths {
+		var content []byte
+		name := filepath.Base(path)
+		if path == "-" {
+			name = "gistfile1.txt"
+			content, err = ioutil.ReadAll(stdin)
+		} else {
+			content, err = ioutil.ReadFile(path)
+		}
+		if err != nil {
+			return
+		}
+
+		if len(bytes.TrimSpace(content)) == 0 {
+			err = fmt.Errorf("Can't create a gist with the empty file %s", path)
+			return
+		}
+
+		if _, ok := files[name]; ok {
+			err = fmt.Errorf("Can't create a gist with two files named %s", name)
+			return
+		}
+
+		files[name] = github.GistFile{Content: string(content)}
+	}
+
+	return
+}
+
+func gistFileNames(gist *github.Gist) (names []string) {
+	for name := range gist.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return
+}
+
+// Gists without a description are described by the names of their files.
+func formatGist(gist github.Gist) string {
+	visibility := "secret"
+	if gist.Public {
+		visibility = "public"
+	}
+
+	description := gist.Description
+	if description == "" {
+		description = strings.Join(gistFileNames(&gist), ", ")
+	}
+
+	return fmt.Sprintf("%s  %s  %s", gist.ID, visibility, description)
+}
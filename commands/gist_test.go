@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	"github.com/jingweno/gh/github"
+)
+
+func TestGistFiles(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	logFile := filepath.Join(dir, "build.log")
+	ioutil.WriteFile(logFile, []byte("ok\n"), 0644)
+
+	files, err := gistFiles([]string{logFile, "-"}, strings.NewReader("FAIL\n"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]github.GistFile{
+		"build.log":     github.GistFile{Content: "ok\n"},
+		"gistfile1.txt": github.GistFile{Content: "FAIL\n"},
+	}, files)
+
+	_, err = gistFiles([]string{"-"}, strings.NewReader("  \n"))
+	assert.Equal(t, "Can't create a gist with the empty file -", err.Error())
+
+	_, err = gistFiles([]string{logFile, logFile}, nil)
+	assert.Equal(t, "Can't create a gist with two files named build.log", err.Error())
+}
+
+func TestFormatGist(t *testing.T) {
+	gist := github.Gist{ID: "8da7fb575debd88c54cf", Description: "build log", Public: true}
+	assert.Equal(t, "8da7fb575debd88c54cf  public  build log", formatGist(gist))
+
+	gist = github.Gist{
+		ID:    "8da7fb575debd88c54cf",
+		Files: map[string]github.GistFile{"b.txt": github.GistFile{}, "a.txt": github.GistFile{}},
+	}
+	assert.Equal(t, "8da7fb575debd88c54cf  secret  a.txt, b.txt", formatGist(gist))
+}
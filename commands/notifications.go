@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jingweno/gh/github"
+	"github.com/jingweno/gh/utils"
+)
+
+var (
+	cmdNotifications = &Command{
+		Run:   notifications,
+		Usage: "notifications [--all] [--participating] [--repo=<OWNER>/<REPO>]",
+		Short: "List your notifications in GitHub",
+		Long: `Lists the ID, subject type, repository, title and reason of your unread
+notifications. With "--all", notifications that have been read are listed as
+well. With "--participating", only the notifications of threads you
+participate in or are mentioned in are listed. With "--repo", only the
+notifications of <OWNER>/<REPO> are listed.
+`}
+
+	cmdReadNotifications = &Command{
+		Key:   "read",
+		Run:   readNotifications,
+		Usage: "notifications read (<ID>|--all [--repo=<OWNER>/<REPO>])",
+		Short: "Mark notifications as read",
+		Long: `Marks the notification <ID> as read, or with "--all", all your notifications,
+or only those of <OWNER>/<REPO> with "--repo".
+`}
+)
+
+var (
+	flagNotificationsAll,
+	flagNotificationsParticipating,
+	flagNotificationsReadAll bool
+
+	flagNotificationsRepo string
+)
+
+func init() {
+	cmdNotifications.Flag.BoolVar(&flagNotificationsAll, "all", false, "ALL")
+	cmdNotifications.Flag.BoolVar(&flagNotificationsParticipating, "participating", false, "PARTICIPATING")
+	cmdNotifications.Flag.StringVar(&flagNotificationsRepo, "repo", "", "REPO")
+
+	cmdReadNotifications.Flag.BoolVar(&flagNotificationsReadAll, "all", false, "ALL")
+	cmdReadNotifications.Flag.StringVar(&flagNotificationsRepo, "repo", "", "REPO")
+
+	cmdNotifications.Use(cmdReadNotifications)
+	CmdRunner.Use(cmdNotifications)
+}
+
+/*
+  $ gh notifications
+  > 1234567890  PullRequest  jingweno/gh  Add repo edit (review_requested)
+
+  $ gh notifications --participating --repo=jingweno/gh
+  > 1234567890  PullRequest  jingweno/gh  Add repo edit (review_requested)
+*/
+func notifications(cmd *Command, args *Args) {
+	project := notificationsProject()
+
+	if args.Noop {
+		fmt.Println("Would request list of notifications")
+		os.Exit(0)
+	}
+
+	gh := github.NewClient(github.DefaultHost())
+	notifications, err := gh.Notifications(project, flagNotificationsAll, flagNotificationsParticipating)
+	utils.Check(err)
+
+	for _, notification := range notifications {
+		fmt.Println(formatNotification(notification))
+	}
+
+	os.Exit(0)
+}
+
+/*
+  $ gh notifications read 1234567890
+  > Marked notification 1234567890 as read
+
+  $ gh notifications read --all --repo=jingweno/gh
+  > Marked notifications of jingweno/gh as read
+*/
+func readNotifications(cmd *Command, args *Args) {
+	if flagNotificationsReadAll == !args.IsParamsEmpty() || args.ParamsSize() > 1 {
+		utils.Check(fmt.Errorf("usage: %s", cmd.FormattedUsage()))
+	}
+
+	project := notificationsProject()
+	if project != nil && !flagNotificationsReadAll {
+		utils.Check(fmt.Errorf("Can't use --repo without --all\nusage: %s", cmd.FormattedUsage()))
+	}
+
+	var id, subject string
+	if flagNotificationsReadAll {
+		subject = "notifications"
+		if project != nil {
+			subject = fmt.Sprintf("notifications of %s", project)
+		}
+	} else {
+		id = args.FirstParam()
+		subject = fmt.Sprintf("notification %s", id)
+	}
+
+	if args.Noop {
+		fmt.Printf("Would mark %s as read\n", subject)
+		os.Exit(0)
+	}
+
+	gh := github.NewClient(github.DefaultHost())
+	if flagNotificationsReadAll {
+		utils.Check(gh.MarkNotificationsRead(project))
+	} else {
+		utils.Check(gh.MarkNotificationRead(id))
+	}
+
+	fmt.Printf("Marked %s as read\n", subject)
+	os.Exit(0)
+}
+
+func notificationsProject() *github.Project {
+	if flagNotificationsRepo == "" {
+		return nil
+	}
+
+	if !regexp.MustCompile(NameWithOwnerRe).MatchString(flagNotificationsRepo) || !strings.Contains(flagNotificationsRepo, "/") {
+		utils.Check(fmt.Errorf("Invalid repository: %s (use <OWNER>/<REPO>)", flagNotificationsRepo))
+	}
+
+	return github.NewProject(flagNotificationsRepo, "", "")
+}
+
+func formatNotification(notification github.Notification) string {
+	return fmt.Sprintf("%s  %s  %s  %s (%s)", notification.ID, notification.Subject.Type,
+		notification.Repository.FullName, notification.Subject.Title, notification.Reason)
+}
@@ -0,0 +1,20 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+	"github.com/jingweno/gh/github"
+	"github.com/jingweno/go-octokit/octokit"
+)
+
+func TestFormatNotification(t *testing.T) {
+	notification := github.Notification{
+		ID:         "1234567890",
+		Reason:     "review_requested",
+		Subject:    github.NotificationSubject{Title: "Add repo edit", Type: "PullRequest"},
+		Repository: octokit.Repository{FullName: "jingweno/gh"},
+	}
+
+	assert.Equal(t, "1234567890  PullRequest  jingweno/gh  Add repo edit (review_requested)", formatNotification(notification))
+}
@@ -2,61 +2,188 @@ package commands
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
+	"github.com/jingweno/gh/git"
 	"github.com/jingweno/gh/github"
 	"github.com/jingweno/gh/utils"
 	"github.com/jingweno/go-octokit/octokit"
+	"hash"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
-	"sync/atomic"
+	"time"
 )
 
 var (
 	cmdRelease = &Command{
 		Run:   release,
-		Usage: "release",
+		Usage: "release [-L <LIMIT>] [--exclude-drafts] [--exclude-prereleases]",
 		Short: "Retrieve releases from GitHub",
-		Long:  `Retrieves releases from GitHub for the project that the "origin" remote points to.`}
+		Long: `Retrieves releases from GitHub for the project that the "origin" remote points to.
+Each release is printed on its own line with its tag, name and whether it's a
+draft or a pre-release.
+
+Show at most <LIMIT> releases with "-L". Drafts and pre-releases can be left out
+with "--exclude-drafts" and "--exclude-prereleases".
+`}
+
+	cmdShowRelease = &Command{
+		Key:   "show",
+		Run:   showRelease,
+		Usage: "release show <TAG>",
+		Short: "Show a release in GitHub",
+		Long: `Shows the release for <TAG> in GitHub for the project that the "origin" remote
+points to, including its target commitish, draft and pre-release flags and the
+size and download count of each uploaded asset.
+`}
+
+	cmdDownloadRelease = &Command{
+		Key:   "download",
+		Run:   downloadRelease,
+		Usage: "release download [-d <DIR>] <TAG>",
+		Short: "Download the assets of a release in GitHub",
+		Long: `Downloads the assets of the release for <TAG> in GitHub for the project that the
+"origin" remote points to into <DIR>, or the current directory without "-d".
+
+If the release has a "SHA512SUMS" or "SHA256SUMS" checksum manifest, as uploaded
+by "release create --checksums", each downloaded asset is verified against it.
+The command fails if any checksum doesn't match.
+`}
+
+	cmdUploadRelease = &Command{
+		Key:   "upload",
+		Run:   uploadRelease,
+		Usage: "release upload [--concurrency=<N>] [--clobber] <TAG> <FILE>...",
+		Short: "Upload assets to a release in GitHub",
+		Long: `Uploads <FILE>s as assets of the existing release for <TAG> in GitHub for the
+project that the "origin" remote points to. If <FILE> is a directory, all the
+files in it are uploaded. The release can still be a draft, so that its assets
+are all in place before it's published.
+
+An asset that already exists on the release with the same name is reported as
+a conflict and nothing is uploaded. With "--clobber", the existing asset is
+deleted and replaced instead.
+`}
 
 	cmdCreateRelease = &Command{
 		Key:   "create",
 		Run:   createRelease,
-		Usage: "release create [-d] [-p] [-a <ASSETS_DIR>] [-m <MESSAGE>|-f <FILE>] <TAG>",
+		Usage: "release create [-d] [-p] [-a <ASSETS_DIR>] [-m <MESSAGE>|-f <FILE>|--generate-notes] [-t <REF>] [--create-tag [--sign-tag]] [--concurrency=<N>] [--resume] [--clobber] [--checksums[=<ALGORITHM>] [-s]] [--bump=<BUMP>] [<TAG>]",
 		Short: "Create a new release in GitHub",
 		Long: `Creates a new release in GitHub for the project that the "origin" remote points to.
-It requires the name of the tag to release as a first argument.
+It requires the name of the tag to release as a first argument, unless "--bump"
+is given.
 
 Specify the assets to include in the release from a directory via "-a". Without
 "-a", it finds assets from "releases/TAG" of the current directory.
 
+Assets are uploaded by <N> concurrent workers, 4 by default. Uploads failing
+with a server or network error are retried up to 3 times. If some assets still
+fail to upload, run the same command again with "--resume": it picks up the
+existing release for <TAG>, deletes the assets left partly uploaded and uploads
+only the assets it doesn't have yet.
+
+An asset that already exists on the release with the same name is reported as
+a conflict and nothing is uploaded. With "--clobber", the existing asset is
+deleted and replaced instead.
+
+With "--checksums", the checksum of each asset is computed while it's uploaded
+and a manifest listing them is uploaded alongside the assets, e.g. "SHA256SUMS"
+for "--checksums sha256". <ALGORITHM> is one of "sha256", the default, or
+"sha512". With "-s", the manifest is also signed with gpg using the
+"user.signingkey" git config and the signature is uploaded as e.g.
+"SHA256SUMS.asc".
+
 Without <MESSAGE> or <FILE>, a text editor will open in which title and body
 of the release can be entered in the same manner as git commit message.
 
+With "--generate-notes", the editor is prefilled with notes listing the pull
+requests and commits merged since the previous tag. Pull requests are grouped
+into sections by label. Sections are configured with one or more
+"gh.releasenotes.section" git config values, or with lines in a
+".gh-release-notes" file at the top of the repository, each in the form of
+"TITLE: LABEL-1, LABEL-2".
+
+With "--bump" and no <TAG>, <TAG> is computed by bumping the highest semantic
+version among the local tags and the tags of the existing releases. <BUMP> is
+one of "major", "minor", "patch" or "pre". A "v" prefix is kept, and bumping a
+pre-release to the release it precedes drops its pre-release part, e.g.
+"v2.0.0-rc.1" bumped by "major" is "v2.0.0". Bumping by "pre" increments the
+pre-release number, e.g. "v1.2.3" to "v1.2.4-0" and "v1.2.4-rc.1" to
+"v1.2.4-rc.2", and creates a pre-release. A <TAG> given with "--bump" is used
+as it is.
+
+The release targets the current branch. Use "-t" to target the commit that <REF>
+resolves to instead, which doesn't need to be checked out.
+
+With "--create-tag", an annotated tag for <TAG> is created locally on the target
+commit and pushed to the remote of the project before the release is created.
+With "--sign-tag", the tag is signed with gpg as with "git tag -s".
+
 If "-d" is given, it creates a draft release.
 
 If "-p" is given, it creates a pre-release.
 `}
 
 	flagReleaseDraft,
-	flagReleasePrerelease bool
+	flagReleasePrerelease,
+	flagReleaseGenerateNotes,
+	flagReleaseResume,
+	flagReleaseSign,
+	flagReleaseSignTag,
+	flagReleaseClobber,
+	flagReleaseCreateTag,
+	flagReleaseExcludeDrafts,
+	flagReleaseExcludePrereleases bool
+
+	flagReleaseLimit,
+	flagReleaseConcurrency int
 
 	flagReleaseAssetsDir,
+	flagReleaseChecksums,
+	flagReleaseTarget,
+	flagReleaseBump,
+	flagReleaseDownloadDir,
 	flagReleaseMessage,
 	flagReleaseFile string
 )
 
 func init() {
+	cmdRelease.Flag.IntVarP(&flagReleaseLimit, "limit", "L", 0, "LIMIT")
+	cmdRelease.Flag.BoolVar(&flagReleaseExcludeDrafts, "exclude-drafts", false, "EXCLUDE_DRAFTS")
+	cmdRelease.Flag.BoolVar(&flagReleaseExcludePrereleases, "exclude-prereleases", false, "EXCLUDE_PRERELEASES")
+
 	cmdCreateRelease.Flag.BoolVarP(&flagReleaseDraft, "draft", "d", false, "DRAFT")
 	cmdCreateRelease.Flag.BoolVarP(&flagReleasePrerelease, "prerelease", "p", false, "PRERELEASE")
 	cmdCreateRelease.Flag.StringVarP(&flagReleaseAssetsDir, "assets", "a", "", "ASSETS_DIR")
 	cmdCreateRelease.Flag.StringVarP(&flagReleaseMessage, "message", "m", "", "MESSAGE")
 	cmdCreateRelease.Flag.StringVarP(&flagReleaseFile, "file", "f", "", "FILE")
+	cmdCreateRelease.Flag.BoolVar(&flagReleaseGenerateNotes, "generate-notes", false, "GENERATE_NOTES")
+	cmdCreateRelease.Flag.IntVar(&flagReleaseConcurrency, "concurrency", 4, "CONCURRENCY")
+	cmdCreateRelease.Flag.BoolVar(&flagReleaseResume, "resume", false, "RESUME")
+	cmdCreateRelease.Flag.StringVar(&flagReleaseChecksums, "checksums", "", "ALGORITHM")
+	cmdCreateRelease.OptionalFlagValues = map[string][]string{"checksums": {"sha256", "sha512"}}
+	cmdCreateRelease.Flag.BoolVarP(&flagReleaseSign, "sign", "s", false, "SIGN")
+
+	cmdCreateRelease.Flag.BoolVar(&flagReleaseClobber, "clobber", false, "CLOBBER")
+	cmdCreateRelease.Flag.StringVarP(&flagReleaseTarget, "target", "t", "", "REF")
+	cmdCreateRelease.Flag.BoolVar(&flagReleaseCreateTag, "create-tag", false, "CREATE_TAG")
+	cmdCreateRelease.Flag.BoolVar(&flagReleaseSignTag, "sign-tag", false, "SIGN_TAG")
+	cmdCreateRelease.Flag.StringVar(&flagReleaseBump, "bump", "", "BUMP")
+
+	cmdUploadRelease.Flag.IntVar(&flagReleaseConcurrency, "concurrency", 4, "CONCURRENCY")
+	cmdUploadRelease.Flag.BoolVar(&flagReleaseClobber, "clobber", false, "CLOBBER")
+
+	cmdDownloadRelease.Flag.StringVarP(&flagReleaseDownloadDir, "dir", "d", "", "DIR")
 
 	cmdRelease.Use(cmdCreateRelease)
+	cmdRelease.Use(cmdShowRelease)
+	cmdRelease.Use(cmdUploadRelease)
+	cmdRelease.Use(cmdDownloadRelease)
 	CmdRunner.Use(cmdRelease)
 }
 
@@ -67,18 +194,14 @@ func release(cmd *Command, args *Args) {
 		} else {
 			releases, err := gh.Releases(project)
 			utils.Check(err)
-			var outputs []string
-			for _, release := range releases {
-				out := fmt.Sprintf("%s (%s)\n%s", release.Name, release.TagName, release.Body)
-				outputs = append(outputs, out)
-			}
 
-			fmt.Println(strings.Join(outputs, "\n\n"))
+			releases = filterReleases(releases, flagReleaseExcludeDrafts, flagReleaseExcludePrereleases, flagReleaseLimit)
+			fmt.Print(formatReleaseList(releases))
 		}
 	})
 }
 
-func createRelease(cmd *Command, args *Args) {
+func showRelease(cmd *Command, args *Args) {
 	if args.IsParamsEmpty() {
 		utils.Check(fmt.Errorf("Missed argument TAG"))
 		return
@@ -86,40 +209,396 @@ func createRelease(cmd *Command, args *Args) {
 
 	tag := args.LastParam()
 
-	assetsDir, err := getAssetsDirectory(flagReleaseAssetsDir, tag)
-	utils.Check(err)
+	runInLocalRepo(func(localRepo *github.GitHubRepo, project *github.Project, gh *github.Client) {
+		if args.Noop {
+			fmt.Printf("Would request release %s for %s\n", tag, project)
+		} else {
+			release, err := gh.Release(project, tag)
+			utils.Check(err)
+
+			fmt.Print(formatRelease(release))
+		}
+	})
+}
+
+func filterReleases(releases []octokit.Release, excludeDrafts, excludePrereleases bool, limit int) (filtered []octokit.Release) {
+	for _, release := range releases {
+		if limit > 0 && len(filtered) == limit {
+			break
+		}
+
+		if (excludeDrafts && release.Draft) || (excludePrereleases && release.Prerelease) {
+			continue
+		}
+
+		filtered = append(filtered, release)
+	}
+
+	return
+}
+
+func formatReleaseList(releases []octokit.Release) string {
+	tagWidth := 0
+	for _, release := range releases {
+		if len(release.TagName) > tagWidth {
+			tagWidth = len(release.TagName)
+		}
+	}
+
+	buffer := bytes.NewBufferString("")
+	for _, release := range releases {
+		line := fmt.Sprintf("%-*s  %s", tagWidth, release.TagName, release.Name)
+		if flags := releaseFlags(release); flags != "" {
+			line = fmt.Sprintf("%s (%s)", line, flags)
+		}
+		buffer.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+
+	return buffer.String()
+}
+
+func formatRelease(release *octokit.Release) string {
+	buffer := bytes.NewBufferString("")
+
+	fmt.Fprintf(buffer, "%s (%s)\n", release.Name, release.TagName)
+	fmt.Fprintf(buffer, "commitish:  %s\n", release.TargetCommitish)
+	fmt.Fprintf(buffer, "draft:      %t\n", release.Draft)
+	fmt.Fprintf(buffer, "prerelease: %t\n", release.Prerelease)
+	if release.PublishedAt != nil {
+		fmt.Fprintf(buffer, "published:  %s\n", release.PublishedAt.Format("2006-01-02 15:04:05 MST"))
+	}
+	fmt.Fprintf(buffer, "url:        %s\n", release.HTMLURL)
+
+	if body := strings.TrimSpace(release.Body); body != "" {
+		fmt.Fprintf(buffer, "\n%s\n", body)
+	}
+
+	fmt.Fprintf(buffer, "\nassets (%d):\n", len(release.Assets))
+	nameWidth := 0
+	for _, asset := range release.Assets {
+		if len(asset.Name) > nameWidth {
+			nameWidth = len(asset.Name)
+		}
+	}
+	for _, asset := range release.Assets {
+		fmt.Fprintf(buffer, "  %-*s  %9s  %d downloads", nameWidth, asset.Name, formatSize(int64(asset.Size)), asset.DownloadCount)
+		if asset.State != "" && asset.State != "uploaded" {
+			fmt.Fprintf(buffer, " (%s)", asset.State)
+		}
+		buffer.WriteString("\n")
+	}
+
+	return buffer.String()
+}
+
+func releaseFlags(release octokit.Release) string {
+	var flags []string
+	if release.Draft {
+		flags = append(flags, "draft")
+	}
+	if release.Prerelease {
+		flags = append(flags, "prerelease")
+	}
+
+	return strings.Join(flags, ", ")
+}
+
+func formatSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", size, units[unit])
+	}
+
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+func createRelease(cmd *Command, args *Args) {
+	if flagReleaseBump == "" && args.IsParamsEmpty() {
+		utils.Check(fmt.Errorf("Missed argument TAG"))
+		return
+	}
+
+	if args.ParamsSize() > 1 {
+		utils.Check(fmt.Errorf("usage: %s", cmd.FormattedUsage()))
+		return
+	}
+
+	if flagReleaseGenerateNotes && (flagReleaseMessage != "" || flagReleaseFile != "") {
+		utils.Check(fmt.Errorf("--generate-notes can't be given with -m or -f"))
+		return
+	}
+
+	if flagReleaseSignTag && !flagReleaseCreateTag {
+		utils.Check(fmt.Errorf("--sign-tag can't be given without --create-tag"))
+		return
+	}
 
 	runInLocalRepo(func(localRepo *github.GitHubRepo, project *github.Project, gh *github.Client) {
-		currentBranch, err := localRepo.CurrentBranch()
+		var tag string
+		if args.IsParamsEmpty() {
+			current, next, err := bumpReleaseTag(gh, project, flagReleaseBump)
+			utils.Check(err)
+
+			tag = next.String()
+			if len(next.Pre) > 0 {
+				flagReleasePrerelease = true
+			}
+			fmt.Printf("Bumping %s to %s\n", current, tag)
+		} else {
+			tag = args.LastParam()
+		}
+
+		assetsDir, err := getAssetsDirectory(flagReleaseAssetsDir, tag)
+		utils.Check(err)
+
+		target, err := releaseTarget(localRepo, flagReleaseTarget)
 		utils.Check(err)
-		branchName := currentBranch.ShortName()
 
-		title, body, err := getTitleAndBodyFromFlags(flagReleaseMessage, flagReleaseFile)
+		if flagReleaseChecksums != "" {
+			_, err = newChecksumHash(flagReleaseChecksums)
+			utils.Check(err)
+		}
+
+		allAssets := findReleaseAssets(assetsDir)
+		assets := allAssets
+
+		var finalRelease *octokit.Release
+		if flagReleaseResume {
+			finalRelease, err = gh.FindRelease(project, tag)
+			utils.Check(err)
+
+			if finalRelease != nil {
+				err = deleteIncompleteReleaseAssets(gh, project, finalRelease)
+				utils.Check(err)
+
+				assets = skipUploadedAssets(finalRelease, assets)
+			}
+		}
+		resumed := finalRelease != nil
+
+		if finalRelease == nil {
+			title, body, err := getTitleAndBodyFromFlags(flagReleaseMessage, flagReleaseFile)
+			utils.Check(err)
+
+			if title == "" {
+				var notes string
+				if flagReleaseGenerateNotes {
+					notes, err = generateReleaseNotes(gh, project, tag, target)
+					utils.Check(err)
+				}
+
+				title, body, err = writeReleaseTitleAndBody(project, tag, target, notes)
+				utils.Check(err)
+			}
+
+			if flagReleaseCreateTag {
+				err = createAndPushTag(localRepo, project, tag, title, target, flagReleaseSignTag)
+				utils.Check(err)
+			}
+
+			params := octokit.ReleaseParams{
+				TagName:         tag,
+				TargetCommitish: target,
+				Name:            title,
+				Body:            body,
+				Draft:           flagReleaseDraft,
+				Prerelease:      flagReleasePrerelease}
+
+			finalRelease, err = gh.CreateRelease(project, params)
+			utils.Check(err)
+		}
+
+		err = resolveReleaseAssetConflicts(gh, project, finalRelease, assets, flagReleaseClobber)
 		utils.Check(err)
 
-		if title == "" {
-			title, body, err = writeReleaseTitleAndBody(project, tag, branchName)
+		succeeded, failed := uploadReleaseAssets(gh, finalRelease, assets, flagReleaseConcurrency, flagReleaseChecksums)
+
+		if flagReleaseChecksums != "" && len(failed) == 0 {
+			checksums, err := releaseChecksums(allAssets, succeeded, flagReleaseChecksums)
+			utils.Check(err)
+
+			dir, manifestAssets, err := writeChecksumManifest(flagReleaseChecksums, checksums, flagReleaseSign)
+			utils.Check(err)
+
+			if flagReleaseResume {
+				manifestAssets = skipUploadedAssets(finalRelease, manifestAssets)
+			}
+
+			err = resolveReleaseAssetConflicts(gh, project, finalRelease, manifestAssets, flagReleaseClobber)
+			utils.Check(err)
+
+			manifestSucceeded, manifestFailed := uploadReleaseAssets(gh, finalRelease, manifestAssets, 1, "")
+			succeeded = append(succeeded, manifestSucceeded...)
+			failed = append(failed, manifestFailed...)
+			os.RemoveAll(dir)
+		}
+
+		fmt.Printf("\n\n%s", formatUploadSummary(succeeded, failed))
+		if resumed {
+			fmt.Printf("\nRelease resumed: %s\n", finalRelease.HTMLURL)
+		} else {
+			fmt.Printf("\nRelease created: %s\n", finalRelease.HTMLURL)
+		}
+
+		if len(failed) > 0 {
+			err = fmt.Errorf("%d of %d assets failed to upload, run again with --resume to retry them", len(failed), len(assets))
 			utils.Check(err)
 		}
+	})
+}
+
+func uploadRelease(cmd *Command, args *Args) {
+	if args.ParamsSize() < 2 {
+		utils.Check(fmt.Errorf("Missed arguments TAG and FILE"))
+		return
+	}
+
+	tag := args.FirstParam()
+
+	var assets []releaseAsset
+	for _, path := range args.Params[1:] {
+		fi, err := os.Stat(path)
+		utils.Check(err)
+
+		if fi.IsDir() {
+			assets = append(assets, findReleaseAssets(path)...)
+		} else {
+			assets = append(assets, releaseAsset{Name: fi.Name(), Path: path, Size: fi.Size()})
+		}
+	}
+
+	runInLocalRepo(func(localRepo *github.GitHubRepo, project *github.Project, gh *github.Client) {
+		if args.Noop {
+			fmt.Printf("Would upload %d asset(s) to release %s for %s\n", len(assets), tag, project)
+			return
+		}
 
-		params := octokit.ReleaseParams{
-			TagName:         tag,
-			TargetCommitish: branchName,
-			Name:            title,
-			Body:            body,
-			Draft:           flagReleaseDraft,
-			Prerelease:      flagReleasePrerelease}
+		release, err := gh.Release(project, tag)
+		utils.Check(err)
 
-		finalRelease, err := gh.CreateRelease(project, params)
+		err = resolveReleaseAssetConflicts(gh, project, release, assets, flagReleaseClobber)
 		utils.Check(err)
 
-		uploadReleaseAssets(gh, finalRelease, assetsDir)
+		succeeded, failed := uploadReleaseAssets(gh, release, assets, flagReleaseConcurrency, "")
+		fmt.Printf("\n\n%s", formatUploadSummary(succeeded, failed))
 
-		fmt.Printf("\n\nRelease created: %s", finalRelease.HTMLURL)
+		if len(failed) > 0 {
+			err = fmt.Errorf("%d of %d assets failed to upload", len(failed), len(assets))
+			utils.Check(err)
+		}
 	})
 }
 
-func writeReleaseTitleAndBody(project *github.Project, tag, currentBranch string) (string, string, error) {
+func downloadRelease(cmd *Command, args *Args) {
+	if args.IsParamsEmpty() {
+		utils.Check(fmt.Errorf("Missed argument TAG"))
+		return
+	}
+
+	tag := args.LastParam()
+
+	dir := flagReleaseDownloadDir
+	if dir == "" {
+		dir = "."
+	}
+
+	runInLocalRepo(func(localRepo *github.GitHubRepo, project *github.Project, gh *github.Client) {
+		if args.Noop {
+			fmt.Printf("Would download assets of release %s for %s into %s\n", tag, project, dir)
+			return
+		}
+
+		release, err := gh.Release(project, tag)
+		utils.Check(err)
+
+		err = os.MkdirAll(dir, 0755)
+		utils.Check(err)
+
+		for _, asset := range release.Assets {
+			fmt.Printf("Downloading %s (%s)\n", asset.Name, formatSize(int64(asset.Size)))
+
+			file, err := os.Create(filepath.Join(dir, asset.Name))
+			utils.Check(err)
+
+			err = gh.DownloadReleaseAsset(&asset, file)
+			file.Close()
+			utils.Check(err)
+		}
+
+		manifest, algorithm := findChecksumManifest(release)
+		if manifest == nil {
+			return
+		}
+
+		mismatches, err := verifyReleaseChecksums(release, dir, manifest, algorithm)
+		utils.Check(err)
+
+		if mismatches > 0 {
+			err = fmt.Errorf("%d asset(s) didn't match the checksums in %s", mismatches, manifest.Name)
+			utils.Check(err)
+		}
+	})
+}
+
+// The release targets the commit <REF> resolves to when it's given with "-t",
+// and the current branch otherwise.
+func releaseTarget(localRepo *github.GitHubRepo, ref string) (string, error) {
+	if ref != "" {
+		sha, err := git.Ref(ref)
+		if err != nil {
+			return "", fmt.Errorf("Aborted: no revision could be determined from '%s'", ref)
+		}
+
+		return sha, nil
+	}
+
+	currentBranch, err := localRepo.CurrentBranch()
+	if err != nil {
+		return "", err
+	}
+
+	return currentBranch.ShortName(), nil
+}
+
+// Tags the target with an annotated tag, signed with sign, and pushes it to
+// the remote of the project so that the release points at it.
+func createAndPushTag(localRepo *github.GitHubRepo, project *github.Project, tag, message, target string, sign bool) error {
+	remoteName := "origin"
+	if remote, err := localRepo.RemoteForProject(project); err == nil {
+		remoteName = remote.Name
+	}
+
+	if message == "" {
+		message = tag
+	}
+
+	tagFlag := "-a"
+	if sign {
+		tagFlag = "-s"
+	}
+
+	err := git.Spawn("tag", tagFlag, tag, "-m", message, target)
+	if err != nil {
+		return fmt.Errorf("Error creating tag %s", tag)
+	}
+
+	err = git.Spawn("push", remoteName, fmt.Sprintf("refs/tags/%s", tag))
+	if err != nil {
+		return fmt.Errorf("Error pushing tag %s to %s", tag, remoteName)
+	}
+
+	return nil
+}
+
+func writeReleaseTitleAndBody(project *github.Project, tag, currentBranch, notes string) (string, string, error) {
 	message := `
 # Creating release %s for %s from %s
 #
@@ -127,6 +606,9 @@ func writeReleaseTitleAndBody(project *github.Project, tag, currentBranch string
 # of the text is the title and the rest is description.
 `
 	message = fmt.Sprintf(message, tag, project.Name, currentBranch)
+	if notes != "" {
+		message = fmt.Sprintf("%s\n\n%s%s", tag, notes, message)
+	}
 
 	editor, err := github.NewEditor("RELEASE", message)
 	if err != nil {
@@ -155,70 +637,347 @@ func getAssetsDirectory(assetsDir, tag string) (string, error) {
 	return assetsDir, nil
 }
 
-func uploadReleaseAssets(gh *github.Client, release *octokit.Release, assetsDir string) {
-	var wg sync.WaitGroup
-	var totalAssets, countAssets uint64
+type releaseAsset struct {
+	Name string
+	Path string
+	Size int64
+}
+
+type releaseAssetUpload struct {
+	Asset    releaseAsset
+	Checksum string
+	Err      error
+}
+
+const releaseAssetUploadAttempts = 3
 
+var releaseAssetRetryDelay = time.Second
+
+func findReleaseAssets(assetsDir string) (assets []releaseAsset) {
 	filepath.Walk(assetsDir, func(path string, fi os.FileInfo, err error) error {
-		if !fi.IsDir() {
-			totalAssets += 1
+		if err == nil && !fi.IsDir() {
+			assets = append(assets, releaseAsset{Name: fi.Name(), Path: path, Size: fi.Size()})
 		}
 		return nil
 	})
 
-	printUploadProgress(&countAssets, totalAssets)
+	return
+}
 
-	filepath.Walk(assetsDir, func(path string, fi os.FileInfo, err error) error {
-		if !fi.IsDir() {
-			wg.Add(1)
+// Assets that have been fully uploaded by a previous run are skipped when
+// resuming.
+func skipUploadedAssets(release *octokit.Release, assets []releaseAsset) (remaining []releaseAsset) {
+	uploaded := make(map[string]bool)
+	for _, asset := range release.Assets {
+		if asset.State == "uploaded" {
+			uploaded[asset.Name] = true
+		}
+	}
 
-			go func() {
-				defer func() {
-					atomic.AddUint64(&countAssets, uint64(1))
-					printUploadProgress(&countAssets, totalAssets)
-					wg.Done()
-				}()
+	for _, asset := range assets {
+		if !uploaded[asset.Name] {
+			remaining = append(remaining, asset)
+		}
+	}
 
-				uploadUrl, err := release.UploadURL.Expand(octokit.M{"name": fi.Name()})
-				utils.Check(err)
+	return
+}
 
-				contentType := detectContentType(path, fi)
+// Assets that a previous run failed to upload are left on the release in the
+// "new" or "starter" state, and have to be deleted before they can be
+// uploaded again.
+func deleteIncompleteReleaseAssets(gh *github.Client, project *github.Project, release *octokit.Release) error {
+	var uploaded []octokit.Asset
+	for _, asset := range release.Assets {
+		if asset.State == "uploaded" {
+			uploaded = append(uploaded, asset)
+			continue
+		}
 
-				file, err := os.Open(path)
-				utils.Check(err)
-				defer file.Close()
+		err := gh.DeleteReleaseAsset(project, &asset)
+		if err != nil {
+			return err
+		}
+	}
+	release.Assets = uploaded
 
-				err = gh.UploadReleaseAsset(uploadUrl, file, contentType)
-				utils.Check(err)
-			}()
+	return nil
+}
+
+// Assets of the release are matched by name with the assets to upload.
+func conflictingReleaseAssets(release *octokit.Release, assets []releaseAsset) (conflicts []octokit.Asset) {
+	names := make(map[string]bool)
+	for _, asset := range assets {
+		names[asset.Name] = true
+	}
+
+	for _, asset := range release.Assets {
+		if names[asset.Name] {
+			conflicts = append(conflicts, asset)
 		}
+	}
+
+	return
+}
 
+// Assets of the release with the same name as one that's about to be
+// uploaded make the upload fail, so they are deleted first with clobber and
+// reported otherwise.
+func resolveReleaseAssetConflicts(gh *github.Client, project *github.Project, release *octokit.Release, assets []releaseAsset, clobber bool) error {
+	conflicts := conflictingReleaseAssets(release, assets)
+	if len(conflicts) == 0 {
 		return nil
-	})
+	}
+
+	if !clobber {
+		var names []string
+		for _, asset := range conflicts {
+			names = append(names, asset.Name)
+		}
 
-	wg.Wait()
+		return fmt.Errorf("Release %s already has asset(s) named %s\n(use `--clobber` to replace them)",
+			release.TagName, strings.Join(names, ", "))
+	}
+
+	for _, asset := range conflicts {
+		err := gh.DeleteReleaseAsset(project, &asset)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func detectContentType(path string, fi os.FileInfo) string {
+// When checksumAlgorithm is given, the checksum of each asset is computed
+// while it's being uploaded.
+func uploadReleaseAssets(gh *github.Client, release *octokit.Release, assets []releaseAsset, concurrency int, checksumAlgorithm string) (succeeded, failed []releaseAssetUpload) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	progress := newUploadProgress(assets)
+	progress.Print()
+
+	jobs := make(chan releaseAsset)
+	results := make(chan releaseAssetUpload)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			for asset := range jobs {
+				checksum, err := uploadReleaseAssetWithRetry(gh, release, asset, progress, checksumAlgorithm)
+				results <- releaseAssetUpload{Asset: asset, Checksum: checksum, Err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for _, asset := range assets {
+			jobs <- asset
+		}
+		close(jobs)
+	}()
+
+	for _ = range assets {
+		upload := <-results
+		progress.Done(upload.Asset)
+		if upload.Err == nil {
+			succeeded = append(succeeded, upload)
+		} else {
+			failed = append(failed, upload)
+		}
+	}
+
+	return
+}
+
+func uploadReleaseAssetWithRetry(gh *github.Client, release *octokit.Release, asset releaseAsset, progress *uploadProgress, checksumAlgorithm string) (checksum string, err error) {
+	delay := releaseAssetRetryDelay
+	for attempt := 1; ; attempt++ {
+		progress.Reset(asset)
+		checksum, err = uploadReleaseAsset(gh, release, asset, progress, checksumAlgorithm)
+		if err == nil || attempt == releaseAssetUploadAttempts || !isTransientError(err) {
+			return
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func uploadReleaseAsset(gh *github.Client, release *octokit.Release, asset releaseAsset, progress *uploadProgress, checksumAlgorithm string) (checksum string, err error) {
+	uploadUrl, err := release.UploadURL.Expand(octokit.M{"name": asset.Name})
+	if err != nil {
+		return
+	}
+
+	contentType, err := detectContentType(asset.Path, asset.Size)
+	if err != nil {
+		return
+	}
+
+	var h hash.Hash
+	if checksumAlgorithm != "" {
+		h, err = newChecksumHash(checksumAlgorithm)
+		if err != nil {
+			return
+		}
+	}
+
+	file, err := os.Open(asset.Path)
+	if err != nil {
+		return
+	}
+
+	reader := &progressReader{ReadCloser: file, onRead: func(p []byte) {
+		progress.Add(asset, int64(len(p)))
+		if h != nil {
+			h.Write(p)
+		}
+	}}
+	defer reader.Close()
+
+	err = gh.UploadReleaseAsset(uploadUrl, reader, contentType, asset.Size)
+	if err == nil && h != nil {
+		checksum = hex.EncodeToString(h.Sum(nil))
+	}
+
+	return
+}
+
+func isTransientError(err error) bool {
+	clientError, ok := err.(*github.ClientError)
+	return ok && clientError.IsTransient()
+}
+
+func detectContentType(path string, size int64) (string, error) {
 	file, err := os.Open(path)
-	utils.Check(err)
+	if err != nil {
+		return "", err
+	}
 	defer file.Close()
 
 	fileHeader := &bytes.Buffer{}
 	headerSize := int64(512)
-	if fi.Size() < headerSize {
-		headerSize = fi.Size()
+	if size < headerSize {
+		headerSize = size
 	}
 
 	// The content type detection only uses 512 bytes at most.
 	// This way we avoid copying the whole content for big files.
 	_, err = io.CopyN(fileHeader, file, headerSize)
-	utils.Check(err)
+	if err != nil {
+		return "", err
+	}
 
-	return http.DetectContentType(fileHeader.Bytes())
+	return http.DetectContentType(fileHeader.Bytes()), nil
 }
 
-func printUploadProgress(count *uint64, total uint64) {
-	out := fmt.Sprintf("Uploading assets (%d/%d)", atomic.LoadUint64(count), total)
-	fmt.Print("\r" + out)
+func formatUploadSummary(succeeded, failed []releaseAssetUpload) string {
+	buffer := bytes.NewBufferString("")
+
+	if len(succeeded) > 0 {
+		fmt.Fprintf(buffer, "Uploaded %d asset(s):\n", len(succeeded))
+		for _, upload := range succeeded {
+			fmt.Fprintf(buffer, "  %s\n", upload.Asset.Name)
+		}
+	}
+
+	if len(failed) > 0 {
+		fmt.Fprintf(buffer, "Failed to upload %d asset(s):\n", len(failed))
+		for _, upload := range failed {
+			fmt.Fprintf(buffer, "  %s: %s\n", upload.Asset.Name, upload.Err)
+		}
+	}
+
+	return buffer.String()
+}
+
+type progressReader struct {
+	io.ReadCloser
+	onRead func(p []byte)
+}
+
+func (r *progressReader) Read(p []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(p)
+	if n > 0 {
+		r.onRead(p[:n])
+	}
+
+	return
+}
+
+// Progress of all uploads is printed on a single line which is redrawn at
+// most every uploadProgressInterval, showing the percentage of each file
+// that is currently uploading.
+type uploadProgress struct {
+	sync.Mutex
+
+	assets    []releaseAsset
+	sent      map[string]int64
+	done      map[string]bool
+	printedAt time.Time
+	lineWidth int
+}
+
+const uploadProgressInterval = 200 * time.Millisecond
+
+func newUploadProgress(assets []releaseAsset) *uploadProgress {
+	return &uploadProgress{
+		assets: assets,
+		sent:   make(map[string]int64),
+		done:   make(map[string]bool),
+	}
+}
+
+func (p *uploadProgress) Add(asset releaseAsset, n int64) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.sent[asset.Path] += n
+	if time.Since(p.printedAt) >= uploadProgressInterval {
+		p.print()
+	}
+}
+
+func (p *uploadProgress) Reset(asset releaseAsset) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.sent[asset.Path] = 0
+}
+
+func (p *uploadProgress) Done(asset releaseAsset) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.done[asset.Path] = true
+	p.print()
+}
+
+func (p *uploadProgress) Print() {
+	p.Lock()
+	defer p.Unlock()
+
+	p.print()
+}
+
+func (p *uploadProgress) print() {
+	out := fmt.Sprintf("Uploading assets (%d/%d)", len(p.done), len(p.assets))
+	for _, asset := range p.assets {
+		sent, started := p.sent[asset.Path]
+		if !started || p.done[asset.Path] || asset.Size == 0 {
+			continue
+		}
+		out = fmt.Sprintf("%s %s %d%%", out, asset.Name, sent*100/asset.Size)
+	}
+
+	// Pad with spaces to clear what's left of a longer previous line
+	padding := p.lineWidth - len(out)
+	if padding < 0 {
+		padding = 0
+	}
+	p.lineWidth = len(out)
+	p.printedAt = time.Now()
+
+	fmt.Print("\r" + out + strings.Repeat(" ", padding))
 }
@@ -0,0 +1,181 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jingweno/gh/git"
+	"github.com/jingweno/gh/github"
+)
+
+var (
+	releaseBumps = []string{"major", "minor", "patch", "pre"}
+
+	semverRegexp = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+)
+
+type semver struct {
+	Prefix string
+	Major  int
+	Minor  int
+	Patch  int
+	Pre    []string
+}
+
+func (v *semver) String() string {
+	version := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if len(v.Pre) > 0 {
+		version = fmt.Sprintf("%s-%s", version, strings.Join(v.Pre, "."))
+	}
+
+	return version
+}
+
+// Compare returns -1, 0 or 1 following the precedence rules of semver: a
+// pre-release has lower precedence than its release, and pre-release
+// identifiers compare numerically when both are numbers.
+func (v *semver) Compare(other *semver) int {
+	if c := compareInts(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareInts(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareInts(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+
+	if len(v.Pre) == 0 || len(other.Pre) == 0 {
+		return -compareInts(len(v.Pre), len(other.Pre))
+	}
+
+	for i := 0; i < len(v.Pre) && i < len(other.Pre); i++ {
+		a, aErr := strconv.Atoi(v.Pre[i])
+		b, bErr := strconv.Atoi(other.Pre[i])
+
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = compareInts(a, b)
+		case aErr == nil:
+			c = -1
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(v.Pre[i], other.Pre[i])
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return compareInts(len(v.Pre), len(other.Pre))
+}
+
+// Bump returns the next version. Bumping a pre-release to the release it
+// precedes drops the pre-release identifiers, e.g. "v2.0.0-rc.1" bumped by
+// "major" is "v2.0.0". Bumping by "pre" increments the last numeric
+// identifier of a pre-release, or starts a pre-release of the next patch.
+func (v *semver) Bump(bump string) (*semver, error) {
+	next := &semver{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	isPre := len(v.Pre) > 0
+
+	switch bump {
+	case "major":
+		if !isPre || v.Minor != 0 || v.Patch != 0 {
+			next.Major, next.Minor, next.Patch = v.Major+1, 0, 0
+		}
+	case "minor":
+		if !isPre || v.Patch != 0 {
+			next.Minor, next.Patch = v.Minor+1, 0
+		}
+	case "patch":
+		if !isPre {
+			next.Patch = v.Patch + 1
+		}
+	case "pre":
+		if !isPre {
+			next.Patch = v.Patch + 1
+			next.Pre = []string{"0"}
+			break
+		}
+
+		next.Pre = append([]string{}, v.Pre...)
+		last := len(next.Pre) - 1
+		if n, err := strconv.Atoi(next.Pre[last]); err == nil {
+			next.Pre[last] = strconv.Itoa(n + 1)
+		} else {
+			next.Pre = append(next.Pre, "0")
+		}
+	default:
+		return nil, fmt.Errorf("Unknown version bump: %s (use %s)", bump, strings.Join(releaseBumps, ", "))
+	}
+
+	return next, nil
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// Tags are parsed with their "v" prefix trimmed the same way as the updater
+// trims it from release names, and the prefix is kept for the next version.
+func parseSemver(tag string) (*semver, bool) {
+	version := strings.TrimPrefix(tag, "v")
+	match := semverRegexp.FindStringSubmatch(version)
+	if match == nil {
+		return nil, false
+	}
+
+	v := &semver{Prefix: strings.TrimSuffix(tag, version)}
+	v.Major, _ = strconv.Atoi(match[1])
+	v.Minor, _ = strconv.Atoi(match[2])
+	v.Patch, _ = strconv.Atoi(match[3])
+	if match[4] != "" {
+		v.Pre = strings.Split(match[4], ".")
+	}
+
+	return v, true
+}
+
+// Tags that aren't semantic versions are ignored. It returns "v0.0.0" when
+// there is no semantic version tag yet.
+func highestSemver(tags []string) *semver {
+	highest := &semver{Prefix: "v"}
+	for _, tag := range tags {
+		if v, ok := parseSemver(tag); ok && v.Compare(highest) > 0 {
+			highest = v
+		}
+	}
+
+	return highest
+}
+
+// Looks for the highest version among the local tags and the tags of the
+// releases of the project, since either of them can be ahead of the other.
+func bumpReleaseTag(gh *github.Client, project *github.Project, bump string) (current, next *semver, err error) {
+	tags, _ := git.Tags()
+
+	releases, err := gh.Releases(project)
+	if err != nil {
+		return
+	}
+	for _, release := range releases {
+		tags = append(tags, release.TagName)
+	}
+
+	current = highestSemver(tags)
+	next, err = current.Bump(bump)
+
+	return
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestParseSemver(t *testing.T) {
+	v, ok := parseSemver("v1.10.0-rc.1")
+	assert.T(t, ok)
+	assert.Equal(t, "v", v.Prefix)
+	assert.Equal(t, 1, v.Major)
+	assert.Equal(t, 10, v.Minor)
+	assert.Equal(t, 0, v.Patch)
+	assert.Equal(t, []string{"rc", "1"}, v.Pre)
+	assert.Equal(t, "v1.10.0-rc.1", v.String())
+
+	v, ok = parseSemver("2.0.1")
+	assert.T(t, ok)
+	assert.Equal(t, "", v.Prefix)
+	assert.Equal(t, "2.0.1", v.String())
+
+	_, ok = parseSemver("release-1")
+	assert.T(t, !ok)
+	_, ok = parseSemver("v1.2")
+	assert.T(t, !ok)
+}
+
+func TestHighestSemver(t *testing.T) {
+	tags := []string{"v1.1.0", "v1.10.0-rc.2", "v1.9.0", "nightly", "v1.10.0-rc.10", "v1.2.0"}
+	assert.Equal(t, "v1.10.0-rc.10", highestSemver(tags).String())
+
+	tags = append(tags, "v1.10.0")
+	assert.Equal(t, "v1.10.0", highestSemver(tags).String())
+
+	assert.Equal(t, "v0.0.0", highestSemver([]string{"nightly"}).String())
+}
+
+func TestSemverBump(t *testing.T) {
+	bumps := []struct {
+		version, bump, next string
+	}{
+		{"v1.9.3", "major", "v2.0.0"},
+		{"v1.9.3", "minor", "v1.10.0"},
+		{"v1.9.3", "patch", "v1.9.4"},
+		{"v1.9.3", "pre", "v1.9.4-0"},
+		{"v2.0.0-rc.1", "major", "v2.0.0"},
+		{"v1.10.0-rc.1", "major", "v2.0.0"},
+		{"v1.10.0-rc.1", "minor", "v1.10.0"},
+		{"v1.10.1-rc.1", "minor", "v1.11.0"},
+		{"v1.10.1-rc.1", "patch", "v1.10.1"},
+		{"v1.10.1-rc.1", "pre", "v1.10.1-rc.2"},
+		{"1.0.0-beta", "pre", "1.0.0-beta.0"},
+	}
+
+	for _, b := range bumps {
+		v, _ := parseSemver(b.version)
+		next, err := v.Bump(b.bump)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, b.next, next.String())
+	}
+
+	v, _ := parseSemver("v1.0.0")
+	_, err := v.Bump("micro")
+	assert.NotEqual(t, nil, err)
+}
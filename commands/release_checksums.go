@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jingweno/gh/cmd"
+	"github.com/jingweno/gh/git"
+	"github.com/jingweno/go-octokit/octokit"
+)
+
+var checksumAlgorithms = []string{"sha512", "sha256"}
+
+func newChecksumHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	}
+
+	return nil, fmt.Errorf("Unknown checksum algorithm: %s (use sha256 or sha512)", algorithm)
+}
+
+// The manifest is named after the coreutils tools that can verify it,
+// e.g. "SHA256SUMS" for sha256sum(1).
+func checksumManifestName(algorithm string) string {
+	return fmt.Sprintf("%sSUMS", strings.ToUpper(algorithm))
+}
+
+func fileChecksum(path, algorithm string) (string, error) {
+	h, err := newChecksumHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(h, file)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func formatChecksumManifest(checksums map[string]string) string {
+	var names []string
+	for name := range checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buffer := bytes.NewBufferString("")
+	for _, name := range names {
+		fmt.Fprintf(buffer, "%s  %s\n", checksums[name], name)
+	}
+
+	return buffer.String()
+}
+
+func parseChecksumManifest(content string) map[string]string {
+	lineRegexp := regexp.MustCompile(`^([0-9a-fA-F]+) [ *](.+)$`)
+
+	checksums := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		match := lineRegexp.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match != nil {
+			checksums[match[2]] = strings.ToLower(match[1])
+		}
+	}
+
+	return checksums
+}
+
+// Writes the manifest to a temporary directory, along with its detached
+// signature when sign is true. The returned assets are ready to be uploaded.
+func writeChecksumManifest(algorithm string, checksums map[string]string, sign bool) (dir string, assets []releaseAsset, err error) {
+	dir, err = ioutil.TempDir("", "gh-checksums")
+	if err != nil {
+		return
+	}
+
+	path := filepath.Join(dir, checksumManifestName(algorithm))
+	err = ioutil.WriteFile(path, []byte(formatChecksumManifest(checksums)), 0644)
+	if err != nil {
+		return
+	}
+
+	if sign {
+		err = signFile(path)
+		if err != nil {
+			return
+		}
+	}
+
+	assets = findReleaseAssets(dir)
+
+	return
+}
+
+// Signs the file with gpg, using "user.signingkey" from git config if it's set.
+func signFile(path string) error {
+	program, err := git.Config("gpg.program")
+	if err != nil || program == "" {
+		program = "gpg"
+	}
+
+	signCmd := cmd.New(program)
+	signCmd.WithArgs("--armor", "--detach-sign", "--yes")
+	if key, err := git.Config("user.signingkey"); err == nil && key != "" {
+		signCmd.WithArgs("--local-user", key)
+	}
+	signCmd.WithArgs("--output", path+".asc", path)
+
+	output, err := signCmd.ExecOutput()
+	if err != nil {
+		return fmt.Errorf("Error signing %s: %s", filepath.Base(path), strings.TrimSpace(output))
+	}
+
+	return nil
+}
+
+func findChecksumManifest(release *octokit.Release) (manifest *octokit.Asset, algorithm string) {
+	for _, algorithm := range checksumAlgorithms {
+		name := checksumManifestName(algorithm)
+		for _, asset := range release.Assets {
+			if asset.Name == name {
+				return &asset, algorithm
+			}
+		}
+	}
+
+	return nil, ""
+}
+
+// Assets that were skipped on upload because a previous run already uploaded
+// them get their checksums computed from the local files.
+func releaseChecksums(assets []releaseAsset, uploads []releaseAssetUpload, algorithm string) (checksums map[string]string, err error) {
+	checksums = make(map[string]string)
+	for _, upload := range uploads {
+		checksums[upload.Asset.Name] = upload.Checksum
+	}
+
+	for _, asset := range assets {
+		if _, ok := checksums[asset.Name]; ok {
+			continue
+		}
+
+		checksums[asset.Name], err = fileChecksum(asset.Path, algorithm)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func verifyReleaseChecksums(release *octokit.Release, dir string, manifest *octokit.Asset, algorithm string) (mismatches int, err error) {
+	content, err := ioutil.ReadFile(filepath.Join(dir, manifest.Name))
+	if err != nil {
+		return
+	}
+
+	checksums := parseChecksumManifest(string(content))
+	for _, asset := range release.Assets {
+		expected, ok := checksums[asset.Name]
+		if !ok {
+			continue
+		}
+
+		var actual string
+		actual, err = fileChecksum(filepath.Join(dir, asset.Name), algorithm)
+		if err != nil {
+			return
+		}
+
+		if actual == expected {
+			fmt.Printf("%s: OK\n", asset.Name)
+		} else {
+			fmt.Printf("%s: FAILED\n", asset.Name)
+			mismatches++
+		}
+	}
+
+	return
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	"github.com/jingweno/go-octokit/octokit"
+)
+
+func TestChecksumManifestName(t *testing.T) {
+	assert.Equal(t, "SHA256SUMS", checksumManifestName("sha256"))
+	assert.Equal(t, "SHA512SUMS", checksumManifestName("sha512"))
+
+	_, err := newChecksumHash("md5")
+	assert.NotEqual(t, nil, err)
+}
+
+func TestFormatAndParseChecksumManifest(t *testing.T) {
+	checksums := map[string]string{
+		"gh_linux.tar.gz": "bbbb",
+		"gh_darwin.zip":   "aaaa",
+	}
+
+	manifest := formatChecksumManifest(checksums)
+	assert.Equal(t, "aaaa  gh_darwin.zip\nbbbb  gh_linux.tar.gz\n", manifest)
+	assert.Equal(t, checksums, parseChecksumManifest(manifest))
+
+	// binary mode entries as written by sha256sum -b
+	parsed := parseChecksumManifest("CCCC *gh_windows.zip\r\n")
+	assert.Equal(t, "cccc", parsed["gh_windows.zip"])
+}
+
+func TestFileChecksum(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "asset")
+	ioutil.WriteFile(path, []byte("hello\n"), 0644)
+
+	checksum, err := fileChecksum(path, "sha256")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03", checksum)
+}
+
+func TestReleaseChecksums(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "skipped")
+	ioutil.WriteFile(path, []byte("hello\n"), 0644)
+
+	assets := []releaseAsset{{Name: "uploaded"}, {Name: "skipped", Path: path}}
+	uploads := []releaseAssetUpload{{Asset: assets[0], Checksum: "abcd"}}
+
+	checksums, err := releaseChecksums(assets, uploads, "sha256")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "abcd", checksums["uploaded"])
+	assert.Equal(t, "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03", checksums["skipped"])
+}
+
+func TestFindChecksumManifest(t *testing.T) {
+	release := &octokit.Release{Assets: []octokit.Asset{
+		{Name: "SHA256SUMS"},
+		{Name: "SHA512SUMS"},
+		{Name: "gh_darwin.zip"},
+	}}
+
+	manifest, algorithm := findChecksumManifest(release)
+	assert.Equal(t, "SHA512SUMS", manifest.Name)
+	assert.Equal(t, "sha512", algorithm)
+
+	manifest, _ = findChecksumManifest(&octokit.Release{})
+	assert.T(t, manifest == nil)
+}
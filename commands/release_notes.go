@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jingweno/gh/git"
+	"github.com/jingweno/gh/github"
+)
+
+const (
+	releaseNotesSectionConfig = "gh.releasenotes.section"
+	releaseNotesFile          = ".gh-release-notes"
+	releaseNotesOtherSection  = "Other Changes"
+)
+
+var (
+	defaultReleaseNotesSections = []string{
+		"Features: feature, enhancement",
+		"Bug Fixes: bug",
+	}
+
+	mergeSubjectRegexp  = regexp.MustCompile(`^Merge pull request #(\d+) from `)
+	squashSubjectRegexp = regexp.MustCompile(`\(#(\d+)\)$`)
+)
+
+type releaseNotesSection struct {
+	Title  string
+	Labels []string
+	Items  []string
+}
+
+func (s *releaseNotesSection) Matches(labels []string) bool {
+	for _, label := range labels {
+		for _, l := range s.Labels {
+			if strings.EqualFold(l, label) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Sections are read from the multi-valued "gh.releasenotes.section" git
+// config first, then from the ".gh-release-notes" file at the top of the
+// working directory. Each entry is in the form of "TITLE: LABEL-1, LABEL-2".
+func loadReleaseNotesSections() []*releaseNotesSection {
+	lines, err := git.ConfigAll(releaseNotesSectionConfig)
+	if err != nil || len(lines) == 0 {
+		lines = readReleaseNotesFile()
+	}
+
+	sections := parseReleaseNotesSections(lines)
+	if len(sections) == 0 {
+		sections = parseReleaseNotesSections(defaultReleaseNotesSections)
+	}
+
+	return sections
+}
+
+func readReleaseNotesFile() []string {
+	dir, err := git.WorkdirName()
+	if err != nil {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, releaseNotesFile))
+	if err != nil {
+		return nil
+	}
+
+	return strings.Split(string(content), "\n")
+}
+
+func parseReleaseNotesSections(lines []string) (sections []*releaseNotesSection) {
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		split := strings.SplitN(line, ":", 2)
+		if len(split) != 2 {
+			continue
+		}
+
+		section := &releaseNotesSection{Title: strings.TrimSpace(split[0])}
+		for _, label := range strings.Split(split[1], ",") {
+			label = strings.TrimSpace(label)
+			if label != "" {
+				section.Labels = append(section.Labels, label)
+			}
+		}
+
+		if section.Title != "" && len(section.Labels) > 0 {
+			sections = append(sections, section)
+		}
+	}
+
+	return
+}
+
+// Finds the pull request number of a merge commit, or of a squashed commit
+// whose subject ends with "(#NUMBER)".
+func parsePullRequestNumberFromSubject(subject string) string {
+	if match := mergeSubjectRegexp.FindStringSubmatch(subject); match != nil {
+		return match[1]
+	}
+
+	if match := squashSubjectRegexp.FindStringSubmatch(subject); match != nil {
+		return match[1]
+	}
+
+	return ""
+}
+
+// The release notes cover the commits between the previous tag and <TAG>, or
+// <TARGET> when <TAG> doesn't exist locally yet.
+func generateReleaseNotes(gh *github.Client, project *github.Project, tag, target string) (string, error) {
+	var end, previousTag string
+	if _, err := git.Ref(tag); err == nil {
+		end = tag
+		previousTag, _ = git.LatestTag(fmt.Sprintf("%s^", tag))
+	} else {
+		end = target
+		previousTag, _ = git.LatestTag(target)
+	}
+
+	commits, err := git.FirstParentLog(previousTag, end)
+	if err != nil {
+		return "", err
+	}
+
+	sections := loadReleaseNotesSections()
+	other := &releaseNotesSection{Title: releaseNotesOtherSection}
+
+	for _, commit := range commits {
+		split := strings.SplitN(commit, " ", 2)
+		sha, subject := split[0], ""
+		if len(split) > 1 {
+			subject = split[1]
+		}
+
+		number := parsePullRequestNumberFromSubject(subject)
+		if number == "" {
+			other.Items = append(other.Items, fmt.Sprintf("%s (%s)", subject, sha))
+			continue
+		}
+
+		var labels []string
+		title := strings.TrimSpace(squashSubjectRegexp.ReplaceAllString(subject, ""))
+		if issue, err := gh.Issue(project, number); err == nil {
+			title = issue.Title
+			for _, label := range issue.Labels {
+				labels = append(labels, label.Name)
+			}
+		}
+
+		item := fmt.Sprintf("%s (#%s)", title, number)
+		added := false
+		for _, section := range sections {
+			if section.Matches(labels) {
+				section.Items = append(section.Items, item)
+				added = true
+				break
+			}
+		}
+		if !added {
+			other.Items = append(other.Items, item)
+		}
+	}
+
+	return formatReleaseNotes(append(sections, other)), nil
+}
+
+func formatReleaseNotes(sections []*releaseNotesSection) string {
+	buffer := bytes.NewBufferString("")
+	for _, section := range sections {
+		if len(section.Items) == 0 {
+			continue
+		}
+
+		if buffer.Len() > 0 {
+			buffer.WriteString("\n")
+		}
+
+		// Headings can't start with "#" since the editor treats those lines
+		// as comments.
+		fmt.Fprintf(buffer, "%s\n%s\n", section.Title, strings.Repeat("-", len(section.Title)))
+		for _, item := range section.Items {
+			fmt.Fprintf(buffer, "* %s\n", item)
+		}
+	}
+
+	return buffer.String()
+}
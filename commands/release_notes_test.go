@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestParseReleaseNotesSections(t *testing.T) {
+	lines := []string{
+		"# sections for release notes",
+		"Features: feature, enhancement",
+		"",
+		"Bug Fixes:bug",
+		"Invalid line",
+		"Empty:",
+	}
+
+	sections := parseReleaseNotesSections(lines)
+	assert.Equal(t, 2, len(sections))
+	assert.Equal(t, "Features", sections[0].Title)
+	assert.Equal(t, []string{"feature", "enhancement"}, sections[0].Labels)
+	assert.Equal(t, "Bug Fixes", sections[1].Title)
+	assert.Equal(t, []string{"bug"}, sections[1].Labels)
+
+	assert.T(t, sections[0].Matches([]string{"docs", "Enhancement"}))
+	assert.T(t, !sections[1].Matches([]string{"feature"}))
+}
+
+func TestParsePullRequestNumberFromSubject(t *testing.T) {
+	assert.Equal(t, "123", parsePullRequestNumberFromSubject("Merge pull request #123 from jingweno/feature"))
+	assert.Equal(t, "45", parsePullRequestNumberFromSubject("Add release notes (#45)"))
+	assert.Equal(t, "", parsePullRequestNumberFromSubject("Fix #45 in the parser"))
+}
+
+func TestFormatReleaseNotes(t *testing.T) {
+	sections := []*releaseNotesSection{
+		{Title: "Features", Items: []string{"Add release show (#1)"}},
+		{Title: "Bug Fixes"},
+		{Title: "Other Changes", Items: []string{"Fix typo (abc1234)"}},
+	}
+
+	notes := formatReleaseNotes(sections)
+	assert.Equal(t, "Features\n--------\n* Add release show (#1)\n\nOther Changes\n-------------\n* Fix typo (abc1234)\n", notes)
+}
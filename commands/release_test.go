@@ -1,10 +1,16 @@
 package commands
 
 import (
+	"fmt"
 	"github.com/bmizerany/assert"
+	"github.com/jingweno/gh/github"
+	"github.com/jingweno/go-octokit/octokit"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 )
 
@@ -50,3 +56,187 @@ func assertAssetsDirSelected(t *testing.T, expectedDir, flagDir string) {
 	assert.Equal(t, nil, err)
 	assert.T(t, os.SameFile(fiExpected, fiAssets))
 }
+
+func TestFilterReleases(t *testing.T) {
+	releases := []octokit.Release{
+		{TagName: "v2.0.0-rc1", Prerelease: true},
+		{TagName: "v1.1.0", Draft: true},
+		{TagName: "v1.0.0"},
+		{TagName: "v0.9.0"},
+	}
+
+	filtered := filterReleases(releases, false, false, 0)
+	assert.Equal(t, 4, len(filtered))
+
+	filtered = filterReleases(releases, true, true, 0)
+	assert.Equal(t, 2, len(filtered))
+	assert.Equal(t, "v1.0.0", filtered[0].TagName)
+
+	filtered = filterReleases(releases, true, false, 2)
+	assert.Equal(t, 2, len(filtered))
+	assert.Equal(t, "v2.0.0-rc1", filtered[0].TagName)
+	assert.Equal(t, "v1.0.0", filtered[1].TagName)
+}
+
+func TestFormatReleaseList(t *testing.T) {
+	releases := []octokit.Release{
+		{TagName: "v1.1.0", Name: "Bugfixes", Draft: true, Prerelease: true},
+		{TagName: "v1.0", Name: "First"},
+	}
+
+	out := formatReleaseList(releases)
+	assert.Equal(t, "v1.1.0  Bugfixes (draft, prerelease)\nv1.0    First\n", out)
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", formatSize(512))
+	assert.Equal(t, "1.5 KB", formatSize(1536))
+	assert.Equal(t, "500.0 MB", formatSize(500*1024*1024))
+	assert.Equal(t, "2.0 GB", formatSize(2*1024*1024*1024))
+}
+
+func TestSkipUploadedAssets(t *testing.T) {
+	release := &octokit.Release{Assets: []octokit.Asset{
+		{Name: "gh_darwin.zip", State: "uploaded"},
+		{Name: "gh_linux.tar.gz", State: "new"},
+	}}
+	assets := []releaseAsset{
+		{Name: "gh_darwin.zip"},
+		{Name: "gh_linux.tar.gz"},
+		{Name: "gh_windows.zip"},
+	}
+
+	remaining := skipUploadedAssets(release, assets)
+	assert.Equal(t, 2, len(remaining))
+	assert.Equal(t, "gh_linux.tar.gz", remaining[0].Name)
+	assert.Equal(t, "gh_windows.zip", remaining[1].Name)
+}
+
+func TestDeleteIncompleteReleaseAssets(t *testing.T) {
+	var deleted []string
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/repos/jingweno/gh/releases/assets/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &github.Client{Credentials: &github.Credentials{Host: "github.com", AccessToken: "123"}}
+	project := &github.Project{Owner: "jingweno", Name: "gh", Host: "github.com"}
+	release := &octokit.Release{TagName: "v1.0.0", Assets: []octokit.Asset{
+		{ID: 1, Name: "gh_darwin.zip", State: "uploaded"},
+		{ID: 2, Name: "gh_linux.tar.gz", State: "new"},
+		{ID: 3, Name: "gh_windows.zip", State: "starter"},
+	}}
+
+	err := deleteIncompleteReleaseAssets(gh, project, release)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"/repos/jingweno/gh/releases/assets/2", "/repos/jingweno/gh/releases/assets/3"}, deleted)
+	assert.Equal(t, 1, len(release.Assets))
+	assert.Equal(t, "gh_darwin.zip", release.Assets[0].Name)
+}
+
+func TestUploadReleaseAssets(t *testing.T) {
+	delay := releaseAssetRetryDelay
+	releaseAssetRetryDelay = 0
+	defer func() { releaseAssetRetryDelay = delay }()
+
+	var mutex sync.Mutex
+	attempts := make(map[string]int)
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/assets", func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		body, _ := ioutil.ReadAll(r.Body)
+
+		mutex.Lock()
+		attempts[name] += 1
+		attempt := attempts[name]
+		mutex.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case name == "flaky.txt" && attempt == 1:
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, `{"message":"Bad Gateway"}`)
+		case name == "invalid.txt":
+			w.WriteHeader(422)
+			fmt.Fprint(w, `{"message":"Validation Failed"}`)
+		default:
+			assert.Equal(t, name, string(body))
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{}`)
+		}
+	})
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"ok.txt", "flaky.txt", "invalid.txt"} {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+	}
+
+	gh := &github.Client{Credentials: &github.Credentials{Host: "github.com", AccessToken: "123"}}
+	release := &octokit.Release{UploadURL: octokit.Hyperlink(server.URL + "/assets{?name}")}
+
+	succeeded, failed := uploadReleaseAssets(gh, release, findReleaseAssets(dir), 2, "sha256")
+	assert.Equal(t, 2, len(succeeded))
+	assert.Equal(t, 1, len(failed))
+	assert.Equal(t, "invalid.txt", failed[0].Asset.Name)
+
+	for _, upload := range succeeded {
+		checksum, _ := fileChecksum(upload.Asset.Path, "sha256")
+		assert.Equal(t, checksum, upload.Checksum)
+	}
+
+	assert.Equal(t, 1, attempts["ok.txt"])
+	assert.Equal(t, 2, attempts["flaky.txt"])
+	assert.Equal(t, 1, attempts["invalid.txt"])
+}
+
+func TestResolveReleaseAssetConflicts(t *testing.T) {
+	var deleted []string
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/repos/jingweno/gh/releases/assets/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &github.Client{Credentials: &github.Credentials{Host: "github.com", AccessToken: "123"}}
+	project := &github.Project{Owner: "jingweno", Name: "gh", Host: "github.com"}
+	release := &octokit.Release{TagName: "v1.0.0", Assets: []octokit.Asset{
+		{ID: 1, Name: "gh_darwin.zip"},
+		{ID: 2, Name: "gh_linux.tar.gz"},
+	}}
+	assets := []releaseAsset{{Name: "gh_linux.tar.gz"}, {Name: "gh_windows.zip"}}
+
+	err := resolveReleaseAssetConflicts(gh, project, release, assets, false)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, "Release v1.0.0 already has asset(s) named gh_linux.tar.gz\n(use `--clobber` to replace them)", err.Error())
+	assert.Equal(t, 0, len(deleted))
+
+	err = resolveReleaseAssetConflicts(gh, project, release, assets, true)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"/repos/jingweno/gh/releases/assets/2"}, deleted)
+
+	err = resolveReleaseAssetConflicts(gh, project, release, []releaseAsset{{Name: "gh_windows.zip"}}, false)
+	assert.Equal(t, nil, err)
+}
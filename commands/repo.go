@@ -0,0 +1,360 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jingweno/gh/github"
+	"github.com/jingweno/gh/utils"
+	flag "github.com/ogier/pflag"
+)
+
+var (
+	cmdRepo = &Command{
+		Run:   repo,
+		Usage: "repo <COMMAND> [<OPTIONS>]",
+		Short: "Manage repositories in GitHub",
+		Long: `Manages repositories in GitHub. See the usage of each <COMMAND> for its
+<OPTIONS>.
+`}
+
+	cmdViewRepo = &Command{
+		Key:   "view",
+		Run:   viewRepo,
+		Usage: "repo view [--web] [<OWNER>/<REPO>]",
+		Short: "Show a repository in GitHub",
+		Long: `Shows the description, homepage, default branch, visibility, fork parent,
+stars, forks, open issue count and clone URLs of <OWNER>/<REPO> in GitHub, or
+of the project that the "origin" remote points to. With "--web", it opens the
+page of the repository in the web browser instead, as "browse" does.
+`}
+
+	cmdDeleteRepo = &Command{
+		Key:   "delete",
+		Run:   deleteRepo,
+		Usage: "repo delete [--yes] <OWNER>/<REPO>",
+		Short: "Delete a repository in GitHub",
+		Long: `Deletes <OWNER>/<REPO> in GitHub, which can't be undone. It asks to type
+<OWNER>/<REPO> again to confirm unless "--yes" is given.
+
+Deleting a repository requires the "delete_repo" scope, which the OAuth token
+created by gh doesn't have by default.
+`}
+
+	cmdEditRepo = &Command{
+		Key:   "edit",
+		Run:   editRepo,
+		Usage: "repo edit [--description=<DESCRIPTION>] [--homepage=<URL>] [--default-branch=<BRANCH>] [--visibility=<VISIBILITY>] [--enable-issues|--disable-issues] [--enable-wiki|--disable-wiki] [--allow-squash-merge[=false]] [--allow-merge-commit[=false]] [--allow-rebase-merge[=false]] [--delete-branch-on-merge[=false]] [<OWNER>/<REPO>]",
+		Short: "Edit the settings of a repository in GitHub",
+		Long: `Changes the settings of <OWNER>/<REPO> in GitHub, or of the project that the
+"origin" remote points to. Only the settings given are changed. <VISIBILITY> is
+one of "public" or "private". The merge settings are turned off with "=false",
+as in "--allow-merge-commit=false".
+`}
+
+	cmdArchiveRepo = &Command{
+		Key:   "archive",
+		Run:   archiveRepo,
+		Usage: "repo archive [--yes] <OWNER>/<REPO>",
+		Short: "Archive a repository in GitHub",
+		Long: `Archives <OWNER>/<REPO> in GitHub, making it read-only. It asks to type
+<OWNER>/<REPO> again to confirm unless "--yes" is given.
+`}
+)
+
+var (
+	flagRepoWeb,
+	flagRepoYes,
+	flagRepoEnableIssues,
+	flagRepoDisableIssues,
+	flagRepoEnableWiki,
+	flagRepoDisableWiki,
+	flagRepoAllowSquashMerge,
+	flagRepoAllowMergeCommit,
+	flagRepoAllowRebaseMerge,
+	flagRepoDeleteBranchOnMerge bool
+
+	flagRepoDescription,
+	flagRepoHomepage,
+	flagRepoDefaultBranch,
+	flagRepoVisibility string
+)
+
+func init() {
+	cmdViewRepo.Flag.BoolVar(&flagRepoWeb, "web", false, "WEB")
+
+	cmdDeleteRepo.Flag.BoolVar(&flagRepoYes, "yes", false, "YES")
+	cmdArchiveRepo.Flag.BoolVar(&flagRepoYes, "yes", false, "YES")
+
+	cmdEditRepo.Flag.StringVar(&flagRepoDescription, "description", "", "DESCRIPTION")
+	cmdEditRepo.Flag.StringVar(&flagRepoHomepage, "homepage", "", "URL")
+	cmdEditRepo.Flag.StringVar(&flagRepoDefaultBranch, "default-branch", "", "BRANCH")
+	cmdEditRepo.Flag.StringVar(&flagRepoVisibility, "visibility", "", "VISIBILITY")
+	cmdEditRepo.Flag.BoolVar(&flagRepoEnableIssues, "enable-issues", false, "ENABLE_ISSUES")
+	cmdEditRepo.Flag.BoolVar(&flagRepoDisableIssues, "disable-issues", false, "DISABLE_ISSUES")
+	cmdEditRepo.Flag.BoolVar(&flagRepoEnableWiki, "enable-wiki", false, "ENABLE_WIKI")
+	cmdEditRepo.Flag.BoolVar(&flagRepoDisableWiki, "disable-wiki", false, "DISABLE_WIKI")
+	cmdEditRepo.Flag.BoolVar(&flagRepoAllowSquashMerge, "allow-squash-merge", false, "ALLOW_SQUASH_MERGE")
+	cmdEditRepo.Flag.BoolVar(&flagRepoAllowMergeCommit, "allow-merge-commit", false, "ALLOW_MERGE_COMMIT")
+	cmdEditRepo.Flag.BoolVar(&flagRepoAllowRebaseMerge, "allow-rebase-merge", false, "ALLOW_REBASE_MERGE")
+	cmdEditRepo.Flag.BoolVar(&flagRepoDeleteBranchOnMerge, "delete-branch-on-merge", false, "DELETE_BRANCH_ON_MERGE")
+
+	cmdRepo.Use(cmdViewRepo)
+	cmdRepo.Use(cmdEditRepo)
+	cmdRepo.Use(cmdDeleteRepo)
+	cmdRepo.Use(cmdArchiveRepo)
+	CmdRunner.Use(cmdRepo)
+}
+
+func repo(cmd *Command, args *Args) {
+	utils.Check(fmt.Errorf("Missed subcommand\n%s", cmd.subCommandsUsage()))
+}
+
+/*
+  $ gh repo view
+  > (prints the metadata of the repository of the current project)
+
+  $ gh repo view jingweno/gh
+  > (prints the metadata of jingweno/gh)
+
+  $ gh repo view --web jingweno/gh
+  > open https://github.com/jingweno/gh
+*/
+func viewRepo(cmd *Command, args *Args) {
+	project := repoProject(args)
+
+	if flagRepoWeb {
+		launcher, err := utils.BrowserLauncher()
+		utils.Check(err)
+
+		args.Replace(launcher[0], "", launcher[1:]...)
+		args.AppendParams(project.WebURL("", "", ""))
+		return
+	}
+
+	gh := github.NewClient(project.Host)
+	repo, err := gh.RepositoryDetails(project)
+	utils.Check(err)
+
+	fmt.Print(formatRepository(repo))
+	os.Exit(0)
+}
+
+// The repository is given as <OWNER>/<REPO>, or <REPO> for one of the
+// current user, and defaults to the main project of the current directory.
+func repoProject(args *Args) *github.Project {
+	if !args.IsParamsEmpty() {
+		return github.NewProject("", args.RemoveParam(0), "")
+	}
+
+	localRepo := github.LocalRepo()
+	project, err := localRepo.MainProject()
+	utils.Check(err)
+
+	return project
+}
+
+/*
+  $ gh repo edit --description="git + hub = github" --disable-wiki
+  > Updated jingweno/gh
+
+  $ gh repo edit --allow-merge-commit=false --allow-squash-merge jingweno/gh
+  > Updated jingweno/gh
+*/
+func editRepo(cmd *Command, args *Args) {
+	changed := make(map[string]bool)
+	cmd.Flag.Visit(func(f *flag.Flag) {
+		changed[f.Name] = true
+	})
+
+	if len(changed) == 0 {
+		utils.Check(fmt.Errorf("Nothing to edit\nusage: %s", cmd.FormattedUsage()))
+	}
+
+	params, err := repoEditParams(changed)
+	utils.Check(err)
+
+	project := repoProject(args)
+
+	if args.Noop {
+		out, err := json.Marshal(params)
+		utils.Check(err)
+		fmt.Printf("Would update %s with %s\n", project, out)
+		os.Exit(0)
+	}
+
+	gh := github.NewClient(project.Host)
+	_, err = gh.EditRepository(project, params)
+	utils.Check(err)
+
+	fmt.Printf("Updated %s\n", project)
+	os.Exit(0)
+}
+
+// Builds the settings to change from the flags that were given, so that the
+// zero value of a flag never overwrites a setting.
+func repoEditParams(changed map[string]bool) (params github.RepositoryEditParams, err error) {
+	if changed["enable-issues"] && changed["disable-issues"] || changed["enable-wiki"] && changed["disable-wiki"] {
+		err = fmt.Errorf("Can't enable and disable a feature at the same time")
+		return
+	}
+
+	if changed["description"] {
+		params.Description = &flagRepoDescription
+	}
+	if changed["homepage"] {
+		params.Homepage = &flagRepoHomepage
+	}
+	if changed["default-branch"] {
+		params.DefaultBranch = &flagRepoDefaultBranch
+	}
+	if changed["visibility"] {
+		if flagRepoVisibility != "public" && flagRepoVisibility != "private" {
+			err = fmt.Errorf("Unknown visibility: %s (use public or private)", flagRepoVisibility)
+			return
+		}
+		private := flagRepoVisibility == "private"
+		params.Private = &private
+	}
+
+	if changed["enable-issues"] {
+		params.HasIssues = &flagRepoEnableIssues
+	} else if changed["disable-issues"] {
+		hasIssues := !flagRepoDisableIssues
+		params.HasIssues = &hasIssues
+	}
+	if changed["enable-wiki"] {
+		params.HasWiki = &flagRepoEnableWiki
+	} else if changed["disable-wiki"] {
+		hasWiki := !flagRepoDisableWiki
+		params.HasWiki = &hasWiki
+	}
+
+	if changed["allow-squash-merge"] {
+		params.AllowSquashMerge = &flagRepoAllowSquashMerge
+	}
+	if changed["allow-merge-commit"] {
+		params.AllowMergeCommit = &flagRepoAllowMergeCommit
+	}
+	if changed["allow-rebase-merge"] {
+		params.AllowRebaseMerge = &flagRepoAllowRebaseMerge
+	}
+	if changed["delete-branch-on-merge"] {
+		params.DeleteBranchOnMerge = &flagRepoDeleteBranchOnMerge
+	}
+
+	return
+}
+
+/*
+  $ gh repo delete jingweno/throwaway
+  > Type jingweno/throwaway to confirm deleting it: jingweno/throwaway
+  > Deleted jingweno/throwaway
+
+  $ gh repo archive --yes jingweno/old
+  > Archived jingweno/old
+*/
+func deleteRepo(cmd *Command, args *Args) {
+	project := confirmRepoAction(cmd, args, "deleting")
+
+	if args.Noop {
+		fmt.Printf("Would delete %s\n", project)
+		os.Exit(0)
+	}
+
+	gh := github.NewClient(project.Host)
+	err := gh.DeleteRepository(project)
+	utils.Check(err)
+
+	fmt.Printf("Deleted %s\n", project)
+	os.Exit(0)
+}
+
+func archiveRepo(cmd *Command, args *Args) {
+	project := confirmRepoAction(cmd, args, "archiving")
+
+	if args.Noop {
+		fmt.Printf("Would archive %s\n", project)
+		os.Exit(0)
+	}
+
+	gh := github.NewClient(project.Host)
+	_, err := gh.ArchiveRepository(project)
+	utils.Check(err)
+
+	fmt.Printf("Archived %s\n", project)
+	os.Exit(0)
+}
+
+// The full name of the repository is required so that a repository of the
+// current directory is never deleted or archived by accident.
+func confirmRepoAction(cmd *Command, args *Args, action string) *github.Project {
+	if args.IsParamsEmpty() || !strings.Contains(args.FirstParam(), "/") {
+		utils.Check(fmt.Errorf("Missed argument OWNER/REPO\nusage: %s", cmd.FormattedUsage()))
+	}
+
+	project := github.NewProject("", args.RemoveParam(0), "")
+	if !flagRepoYes && !args.Noop {
+		fmt.Printf("Type %s to confirm %s it: ", project, action)
+		if !confirmName(os.Stdin, project.String()) {
+			utils.Check(fmt.Errorf("Aborted: the name doesn't match %s", project))
+		}
+	}
+
+	return project
+}
+
+func confirmName(in io.Reader, name string) bool {
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false
+	}
+
+	return strings.TrimSpace(line) == name
+}
+
+func formatRepository(repo *github.RepositoryDetails) string {
+	visibility := "public"
+	if repo.Private {
+		visibility = "private"
+	}
+
+	buffer := bytes.NewBufferString("")
+	fmt.Fprintf(buffer, "%s\n", repo.FullName)
+	if repo.Description != "" {
+		fmt.Fprintf(buffer, "%s\n", repo.Description)
+	}
+	fmt.Fprintln(buffer)
+
+	fields := [][]string{
+		{"Homepage", repo.Homepage},
+		{"Default branch", repo.DefaultBranch},
+		{"Visibility", visibility},
+	}
+	if repo.Parent != nil {
+		fields = append(fields, []string{"Fork of", repo.Parent.FullName})
+	}
+	fields = append(fields,
+		[]string{"Stars", fmt.Sprintf("%d", repo.WatchersCount)},
+		[]string{"Forks", fmt.Sprintf("%d", repo.ForksCount)},
+		[]string{"Open issues", fmt.Sprintf("%d", repo.OpenIssues)},
+		[]string{"Clone (HTTPS)", repo.CloneURL},
+		[]string{"Clone (SSH)", repo.SSHURL},
+	)
+	if !repo.Private {
+		fields = append(fields, []string{"Clone (git)", repo.GitURL})
+	}
+
+	for _, field := range fields {
+		if field[1] != "" {
+			fmt.Fprintf(buffer, "%-15s %s\n", field[0]+":", field[1])
+		}
+	}
+
+	return buffer.String()
+}
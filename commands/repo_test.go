@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	"github.com/jingweno/gh/github"
+	"github.com/jingweno/go-octokit/octokit"
+)
+
+func TestFormatRepository(t *testing.T) {
+	var repo *github.RepositoryDetails
+	err := json.Unmarshal([]byte(`{
+		"full_name": "octocat/gh",
+		"description": "git + hub = github",
+		"private": false,
+		"fork": true,
+		"default_branch": "main",
+		"parent": {"full_name": "jingweno/gh", "default_branch": "master"},
+		"watchers_count": 3,
+		"forks_count": 1,
+		"open_issues_count": 2,
+		"open_issues": 2,
+		"clone_url": "https://github.com/octocat/gh.git",
+		"ssh_url": "git@github.com:octocat/gh.git",
+		"git_url": "git://github.com/octocat/gh.git"
+	}`), &repo)
+	assert.Equal(t, nil, err)
+
+	expected := `octocat/gh
+git + hub = github
+
+Default branch: main
+Visibility:     public
+Fork of:        jingweno/gh
+Stars:          3
+Forks:          1
+Open issues:    2
+Clone (HTTPS):  https://github.com/octocat/gh.git
+Clone (SSH):    git@github.com:octocat/gh.git
+Clone (git):    git://github.com/octocat/gh.git
+`
+	assert.Equal(t, expected, formatRepository(repo))
+
+	repo = &github.RepositoryDetails{Repository: octokit.Repository{FullName: "octocat/secret", Private: true, GitURL: "git://github.com/octocat/secret.git"}}
+	expected = `octocat/secret
+
+Visibility:     private
+Stars:          0
+Forks:          0
+Open issues:    0
+`
+	assert.Equal(t, expected, formatRepository(repo))
+}
+
+// The command exits by itself so that the runner doesn't go on running
+// "git repo view", which is checked in a separate process.
+func TestViewRepoExits(t *testing.T) {
+	if os.Getenv("GH_TEST_VIEW_REPO") != "" {
+		github.CreateTestConfigs("jingweno", "123")
+		CmdRunner.Call(cmdRepo, NewArgs([]string{"repo", "view", "jingweno/gh"}))
+		fmt.Println("ran git")
+		os.Exit(1)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/jingweno/gh", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"full_name": "jingweno/gh"}`)
+	}))
+	defer server.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestViewRepoExits")
+	cmd.Env = append(os.Environ(), "GH_TEST_VIEW_REPO=1", "GH_API_HOST="+server.URL)
+	out, err := cmd.CombinedOutput()
+	assert.Equal(t, nil, err)
+	assert.T(t, strings.HasPrefix(string(out), "jingweno/gh\n"))
+	assert.T(t, !strings.Contains(string(out), "ran git"))
+}
+
+func TestConfirmName(t *testing.T) {
+	assert.T(t, confirmName(strings.NewReader("jingweno/gh\n"), "jingweno/gh"))
+	assert.T(t, confirmName(strings.NewReader("  jingweno/gh"), "jingweno/gh"))
+	assert.T(t, !confirmName(strings.NewReader("gh\n"), "jingweno/gh"))
+	assert.T(t, !confirmName(strings.NewReader(""), "jingweno/gh"))
+}
+
+func TestRepoEditParams(t *testing.T) {
+	flagRepoVisibility = "private"
+	flagRepoDisableIssues = true
+	flagRepoAllowSquashMerge = false
+	defer func() {
+		flagRepoVisibility = ""
+		flagRepoDisableIssues = false
+	}()
+
+	changed := map[string]bool{"visibility": true, "disable-issues": true, "allow-squash-merge": true}
+	params, err := repoEditParams(changed)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, *params.Private)
+	assert.Equal(t, false, *params.HasIssues)
+	assert.Equal(t, false, *params.AllowSquashMerge)
+	assert.Equal(t, (*string)(nil), params.Description)
+	assert.Equal(t, (*bool)(nil), params.HasWiki)
+
+	flagRepoVisibility = "internal"
+	_, err = repoEditParams(map[string]bool{"visibility": true})
+	assert.Equal(t, "Unknown visibility: internal (use public or private)", err.Error())
+
+	_, err = repoEditParams(map[string]bool{"enable-wiki": true, "disable-wiki": true})
+	assert.NotEqual(t, nil, err)
+
+	flagRepoEnableIssues = false
+	flagRepoDisableWiki = false
+	params, err = repoEditParams(map[string]bool{"enable-issues": true, "disable-wiki": true})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, *params.HasIssues)
+	assert.Equal(t, true, *params.HasWiki)
+
+	flagRepoEnableWiki = true
+	defer func() { flagRepoEnableWiki = false }()
+	params, err = repoEditParams(map[string]bool{"enable-wiki": true})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, *params.HasWiki)
+	assert.Equal(t, (*bool)(nil), params.HasIssues)
+}
@@ -0,0 +1,186 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jingweno/gh/github"
+	"github.com/jingweno/gh/utils"
+)
+
+var (
+	cmdSearch = &Command{
+		Run:   search,
+		Usage: "search <COMMAND> [<OPTIONS>] <QUERY>",
+		Short: "Search repositories, issues and code in GitHub",
+		Long: `Searches GitHub for repositories, issues or code matching <QUERY>, which
+can contain any of the qualifiers of the GitHub search syntax. See the usage
+of each <COMMAND> for its <OPTIONS>.
+`}
+
+	cmdSearchRepos = &Command{
+		Key:   "repos",
+		Run:   searchRepos,
+		Usage: "search repos [--owner=<OWNER>] [--language=<LANGUAGE>] [-L <LIMIT>] <QUERY>",
+		Short: "Search repositories in GitHub",
+		Long: `Lists the repositories matching <QUERY>, owned by the user or organization
+<OWNER> and written in <LANGUAGE> when given. At most <LIMIT> results are
+shown, 30 by default.
+`}
+
+	cmdSearchIssues = &Command{
+		Key:   "issues",
+		Run:   searchIssues,
+		Usage: "search issues [--owner=<OWNER>] [--state=<STATE>] [--label=<LABEL>] [-L <LIMIT>] <QUERY>",
+		Short: "Search issues and pull requests in GitHub",
+		Long: `Lists the issues and pull requests matching <QUERY>, in the repositories of
+<OWNER>, with <STATE> of "open" or "closed" and labeled <LABEL> when given. At
+most <LIMIT> results are shown, 30 by default.
+`}
+
+	cmdSearchCode = &Command{
+		Key:   "code",
+		Run:   searchCode,
+		Usage: "search code [--owner=<OWNER>] [--language=<LANGUAGE>] [-L <LIMIT>] <QUERY>",
+		Short: "Search code in GitHub",
+		Long: `Lists the files matching <QUERY>, in the repositories of <OWNER> and
+written in <LANGUAGE> when given. At most <LIMIT> results are shown, 30 by
+default.
+`}
+)
+
+var (
+	flagSearchOwner,
+	flagSearchLanguage,
+	flagSearchState,
+	flagSearchLabel string
+
+	flagSearchLimit int
+)
+
+func init() {
+	for _, cmd := range []*Command{cmdSearchRepos, cmdSearchIssues, cmdSearchCode} {
+		cmd.Flag.StringVar(&flagSearchOwner, "owner", "", "OWNER")
+		cmd.Flag.IntVarP(&flagSearchLimit, "limit", "L", 30, "LIMIT")
+		cmdSearch.Use(cmd)
+	}
+
+	cmdSearchRepos.Flag.StringVar(&flagSearchLanguage, "language", "", "LANGUAGE")
+	cmdSearchCode.Flag.StringVar(&flagSearchLanguage, "language", "", "LANGUAGE")
+	cmdSearchIssues.Flag.StringVar(&flagSearchState, "state", "", "STATE")
+	cmdSearchIssues.Flag.StringVar(&flagSearchLabel, "label", "", "LABEL")
+
+	CmdRunner.Use(cmdSearch)
+}
+
+func search(cmd *Command, args *Args) {
+	utils.Check(fmt.Errorf("Missed subcommand\n%s", cmd.subCommandsUsage()))
+}
+
+/*
+  $ gh search repos --language=go --owner=jingweno octokit
+  > jingweno/go-octokit ( https://github.com/jingweno/go-octokit )
+*/
+func searchRepos(cmd *Command, args *Args) {
+	query := searchCommandQuery(cmd, args)
+	if args.Noop {
+		fmt.Printf("Would search repositories for %s\n", query)
+		os.Exit(0)
+	}
+
+	gh := github.NewClient(github.DefaultHost())
+	repos, err := gh.SearchRepositories(query, flagSearchLimit)
+	utils.Check(err)
+
+	for _, repo := range repos {
+		fmt.Printf("%s ( %s )\n", repo.FullName, repo.HTMLURL)
+	}
+
+	os.Exit(0)
+}
+
+/*
+  $ gh search issues --state=open --label=bug "ci-status"
+  >     123] ci-status fails without a remote ( https://github.com/jingweno/gh/issues/123 )
+*/
+func searchIssues(cmd *Command, args *Args) {
+	if flagSearchState != "" && flagSearchState != "open" && flagSearchState != "closed" {
+		utils.Check(fmt.Errorf("Unknown state: %s (use open or closed)", flagSearchState))
+	}
+
+	query := searchCommandQuery(cmd, args)
+	if args.Noop {
+		fmt.Printf("Would search issues for %s\n", query)
+		os.Exit(0)
+	}
+
+	gh := github.NewClient(github.DefaultHost())
+	issues, err := gh.SearchIssues(query, flagSearchLimit)
+	utils.Check(err)
+
+	for _, issue := range issues {
+		fmt.Printf("% 7d] %s ( %s )\n", issue.Number, issue.Title, issue.HTMLURL)
+	}
+
+	os.Exit(0)
+}
+
+/*
+  $ gh search code --owner=jingweno --language=go NewClient
+  > jingweno/gh:github/client.go ( https://github.com/jingweno/gh/blob/master/github/client.go )
+*/
+func searchCode(cmd *Command, args *Args) {
+	query := searchCommandQuery(cmd, args)
+	if args.Noop {
+		fmt.Printf("Would search code for %s\n", query)
+		os.Exit(0)
+	}
+
+	gh := github.NewClient(github.DefaultHost())
+	results, err := gh.SearchCode(query, flagSearchLimit)
+	utils.Check(err)
+
+	for _, result := range results {
+		fmt.Printf("%s:%s ( %s )\n", result.Repository.FullName, result.Path, result.HTMLURL)
+	}
+
+	os.Exit(0)
+}
+
+func searchCommandQuery(cmd *Command, args *Args) string {
+	if args.IsParamsEmpty() {
+		utils.Check(fmt.Errorf("Missing <QUERY>\nusage: %s", cmd.FormattedUsage()))
+	}
+
+	if flagSearchLimit < 1 {
+		utils.Check(fmt.Errorf("The limit must be positive: %d", flagSearchLimit))
+	}
+
+	qualifiers := [][]string{
+		{"user", flagSearchOwner},
+		{"language", flagSearchLanguage},
+		{"state", flagSearchState},
+		{"label", flagSearchLabel},
+	}
+
+	return searchQuery(args.Params, qualifiers)
+}
+
+// Qualifiers without a value are left out, and values with spaces are quoted.
+func searchQuery(words []string, qualifiers [][]string) string {
+	query := strings.Join(words, " ")
+	for _, qualifier := range qualifiers {
+		name, value := qualifier[0], qualifier[1]
+		if value == "" {
+			continue
+		}
+
+		if strings.Contains(value, " ") {
+			value = fmt.Sprintf("%q", value)
+		}
+		query = fmt.Sprintf("%s %s:%s", query, name, value)
+	}
+
+	return query
+}
@@ -0,0 +1,14 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestSearchQuery(t *testing.T) {
+	qualifiers := [][]string{{"user", "jingweno"}, {"language", ""}, {"label", "good first issue"}}
+	assert.Equal(t, `deprecated api user:jingweno label:"good first issue"`, searchQuery([]string{"deprecated", "api"}, qualifiers))
+
+	assert.Equal(t, "octokit", searchQuery([]string{"octokit"}, nil))
+}
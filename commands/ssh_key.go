@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jingweno/gh/github"
+	"github.com/jingweno/gh/utils"
+)
+
+var (
+	cmdSSHKey = &Command{
+		Run:   sshKey,
+		Usage: "ssh-key <COMMAND> [<OPTIONS>]",
+		Short: "Manage the SSH keys of your GitHub account",
+		Long: `Manages the SSH keys of your GitHub account, which "clone -p" and the other
+commands using the SSH protocol rely on. See the usage of each <COMMAND> for
+its <OPTIONS>.
+`}
+
+	cmdListSSHKey = &Command{
+		Key:   "list",
+		Run:   listSSHKey,
+		Usage: "ssh-key list",
+		Short: "List the SSH keys of your GitHub account",
+		Long: `Lists the ID, title and key of the SSH keys of your GitHub account.
+`}
+
+	cmdAddSSHKey = &Command{
+		Key:   "add",
+		Run:   addSSHKey,
+		Usage: "ssh-key add [-t <TITLE>] [<FILE>]",
+		Short: "Add an SSH key to your GitHub account",
+		Long: `Adds the public key in <FILE> to your GitHub account, by default the first of
+"~/.ssh/id_ed25519.pub" or "~/.ssh/id_rsa.pub" that exists. The key is titled
+<TITLE>, or by the comment of the key, or by the name of this machine.
+`}
+
+	cmdDeleteSSHKey = &Command{
+		Key:   "delete",
+		Run:   deleteSSHKey,
+		Usage: "ssh-key delete <ID>",
+		Short: "Delete an SSH key from your GitHub account",
+		Long: `Deletes the SSH key <ID> from your GitHub account, as listed by "ssh-key list".
+`}
+)
+
+var (
+	flagSSHKeyTitle string
+
+	defaultPublicKeyFiles = []string{"id_ed25519.pub", "id_rsa.pub"}
+)
+
+func init() {
+	cmdAddSSHKey.Flag.StringVarP(&flagSSHKeyTitle, "title", "t", "", "TITLE")
+
+	cmdSSHKey.Use(cmdListSSHKey)
+	cmdSSHKey.Use(cmdAddSSHKey)
+	cmdSSHKey.Use(cmdDeleteSSHKey)
+	CmdRunner.Use(cmdSSHKey)
+}
+
+func sshKey(cmd *Command, args *Args) {
+	utils.Check(fmt.Errorf("Missed subcommand\n%s", cmd.subCommandsUsage()))
+}
+
+/*
+  $ gh ssh-key list
+  > 1234567  laptop  ssh-ed25519 ...aWzqVOD7bKkB
+*/
+func listSSHKey(cmd *Command, args *Args) {
+	if args.Noop {
+		fmt.Println("Would request list of SSH keys")
+		os.Exit(0)
+	}
+
+	gh := github.NewClient(github.DefaultHost())
+	keys, err := gh.PublicKeys()
+	utils.Check(err)
+
+	for _, key := range keys {
+		fmt.Println(formatPublicKey(key))
+	}
+
+	os.Exit(0)
+}
+
+/*
+  $ gh ssh-key add
+  > Added SSH key 1234567 (jingweno@laptop)
+
+  $ gh ssh-key add -t work ~/.ssh/work.pub
+  > Added SSH key 1234568 (work)
+*/
+func addSSHKey(cmd *Command, args *Args) {
+	if args.ParamsSize() > 1 {
+		utils.Check(fmt.Errorf("usage: %s", cmd.FormattedUsage()))
+	}
+
+	var file string
+	if args.IsParamsEmpty() {
+		file = defaultPublicKeyFile(filepath.Join(os.Getenv("HOME"), ".ssh"))
+		if file == "" {
+			utils.Check(fmt.Errorf("Can't find an SSH public key in ~/.ssh (tried %s)", strings.Join(defaultPublicKeyFiles, ", ")))
+		}
+	} else {
+		file = args.FirstParam()
+	}
+
+	content, err := ioutil.ReadFile(file)
+	utils.Check(err)
+
+	key, comment, err := parsePublicKey(string(content))
+	if err != nil {
+		utils.Check(fmt.Errorf("%s: %s", file, err))
+	}
+
+	title := flagSSHKeyTitle
+	if title == "" {
+		title = comment
+	}
+	if title == "" {
+		title, _ = os.Hostname()
+	}
+
+	if args.Noop {
+		fmt.Printf("Would add SSH key %s (%s)\n", file, title)
+		os.Exit(0)
+	}
+
+	gh := github.NewClient(github.DefaultHost())
+	publicKey, err := gh.AddPublicKey(title, key)
+	utils.Check(err)
+
+	fmt.Printf("Added SSH key %d (%s)\n", publicKey.ID, publicKey.Title)
+	os.Exit(0)
+}
+
+/*
+  $ gh ssh-key delete 1234567
+  > Deleted SSH key 1234567
+*/
+func deleteSSHKey(cmd *Command, args *Args) {
+	if args.ParamsSize() != 1 {
+		utils.Check(fmt.Errorf("usage: %s", cmd.FormattedUsage()))
+	}
+
+	id := args.FirstParam()
+	if args.Noop {
+		fmt.Printf("Would delete SSH key %s\n", id)
+		os.Exit(0)
+	}
+
+	gh := github.NewClient(github.DefaultHost())
+	utils.Check(gh.DeletePublicKey(id))
+
+	fmt.Printf("Deleted SSH key %s\n", id)
+	os.Exit(0)
+}
+
+func defaultPublicKeyFile(sshDir string) string {
+	for _, name := range defaultPublicKeyFiles {
+		file := filepath.Join(sshDir, name)
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+	}
+
+	return ""
+}
+
+// A public key is made of its type and base64 encoded key, optionally
+// followed by a comment.
+func parsePublicKey(content string) (key, comment string, err error) {
+	if strings.Contains(content, "PRIVATE KEY") {
+		err = fmt.Errorf("not a public key, but a private key")
+		return
+	}
+
+	fields := strings.Fields(content)
+	if len(fields) < 2 || !strings.Contains(fields[0], "-") {
+		err = fmt.Errorf("not an SSH public key")
+		return
+	}
+
+	key = strings.Join(fields[:2], " ")
+	comment = strings.Join(fields[2:], " ")
+
+	return
+}
+
+// Only the end of the key is shown, which is enough to tell keys apart.
+func formatPublicKey(key github.PublicKey) string {
+	fields := strings.Fields(key.Key)
+	if len(fields) == 2 && len(fields[1]) > 12 {
+		key.Key = fmt.Sprintf("%s ...%s", fields[0], fields[1][len(fields[1])-12:])
+	}
+
+	return fmt.Sprintf("%d  %s  %s", key.ID, key.Title, key.Key)
+}
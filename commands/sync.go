@@ -0,0 +1,234 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/jingweno/gh/git"
+	"github.com/jingweno/gh/github"
+	"github.com/jingweno/gh/utils"
+	"os"
+	"strings"
+)
+
+var cmdSync = &Command{
+	Run:   syncFork,
+	Usage: "sync [BRANCH]",
+	Short: "Fast-forward local branches and the fork to match the upstream repository",
+	Long: `Fetches the parent repository of the fork that the "origin" remote points to,
+or "origin" itself when it isn't a fork. Local branches that track BRANCH of
+either the parent or the fork are then fast-forwarded to the parent's BRANCH,
+and BRANCH of the fork is pushed to match it. BRANCH defaults to the default
+branch of the parent repository.
+
+Branches that have diverged from the parent are reported and left as they
+are instead of being merged.
+`,
+}
+
+func init() {
+	CmdRunner.Use(cmdSync)
+}
+
+/*
+  $ gh sync
+  > git fetch upstream
+  > git fetch origin
+  > git merge --ff-only --quiet refs/remotes/upstream/master
+  > git push origin refs/remotes/upstream/master:refs/heads/master
+
+  $ gh sync gh-pages
+  > git fetch upstream
+  > git fetch origin
+  > git update-ref refs/heads/gh-pages refs/remotes/upstream/gh-pages
+  > git push origin refs/remotes/upstream/gh-pages:refs/heads/gh-pages
+*/
+func syncFork(cmd *Command, args *Args) {
+	localRepo := github.LocalRepo()
+
+	parent, fork, defaultBranch, err := syncRemotes(localRepo)
+	utils.Check(err)
+
+	remotes := []*github.Remote{parent}
+	if fork != nil {
+		remotes = append(remotes, fork)
+	}
+	for _, remote := range remotes {
+		if args.Noop {
+			fmt.Printf("git fetch %s\n", remote.Name)
+		} else {
+			utils.Check(git.Spawn("fetch", remote.Name))
+		}
+	}
+
+	branch := defaultBranch
+	if !args.IsParamsEmpty() {
+		branch = args.FirstParam()
+	}
+
+	target := fmt.Sprintf("refs/remotes/%s/%s", parent.Name, branch)
+	targetSha, err := git.Ref(target)
+	if err != nil {
+		utils.Check(fmt.Errorf("Aborted: %s has no branch %s", parent.Name, branch))
+	}
+
+	var commands [][]string
+	diverged := false
+
+	var forkTarget string
+	if fork != nil {
+		forkTarget = fmt.Sprintf("refs/remotes/%s/%s", fork.Name, branch)
+	}
+
+	localBranches, err := git.LocalBranches()
+	utils.Check(err)
+	currentBranch, _ := localRepo.CurrentBranch()
+
+	for _, name := range localBranches {
+		localBranch := &github.Branch{Name: name}
+		upstream, err := localBranch.Upstream()
+		if err != nil || upstream.Name != target && (forkTarget == "" || upstream.Name != forkTarget) {
+			continue
+		}
+
+		sha, err := git.Ref(name)
+		if err != nil {
+			continue
+		}
+
+		switch syncState(sha, targetSha, git.IsAncestor) {
+		case syncUpToDate:
+			continue
+		case syncDiverged:
+			fmt.Fprintf(os.Stderr, "warning: %s has diverged from %s/%s, not updating it\n", localBranch.ShortName(), parent.Name, branch)
+			diverged = true
+			continue
+		}
+
+		if currentBranch != nil && currentBranch.Name == name {
+			commands = append(commands, []string{"git", "merge", "--ff-only", "--quiet", target})
+		} else {
+			commands = append(commands, []string{"git", "update-ref", name, target, sha})
+		}
+		commands = append(commands, []string{"echo", fmt.Sprintf("Updated branch %s (was %s)", localBranch.ShortName(), sha[:7])})
+	}
+
+	if fork != nil {
+		// A branch missing from the fork is pushed as well
+		state := syncFastForward
+		if forkSha, err := git.Ref(forkTarget); err == nil {
+			state = syncState(forkSha, targetSha, git.IsAncestor)
+		}
+
+		switch state {
+		case syncFastForward:
+			refspec := fmt.Sprintf("%s:refs/heads/%s", target, branch)
+			commands = append(commands, []string{"git", "push", fork.Name, refspec})
+		case syncDiverged:
+			fmt.Fprintf(os.Stderr, "warning: %s/%s has diverged from %s/%s, not pushing it\n", fork.Name, branch, parent.Name, branch)
+			diverged = true
+		}
+	}
+
+	if len(commands) == 0 {
+		if !diverged {
+			fmt.Println("Everything up-to-date")
+		}
+		os.Exit(0)
+	}
+
+	args.Replace(commands[0][0], commands[0][1], commands[0][2:]...)
+	for _, c := range commands[1:] {
+		args.After(c...)
+	}
+}
+
+const (
+	syncUpToDate = iota
+	syncFastForward
+	syncDiverged
+)
+
+// A ref that is at or ahead of the target is up to date, one that the target
+// descends from can be fast-forwarded to it, and any other has diverged.
+func syncState(sha, targetSha string, isAncestor func(ancestor, ref string) bool) int {
+	if sha == targetSha || isAncestor(targetSha, sha) {
+		return syncUpToDate
+	}
+
+	if isAncestor(sha, targetSha) {
+		return syncFastForward
+	}
+
+	return syncDiverged
+}
+
+// The parent remote points to the repository that "origin" was forked from,
+// and "origin" is the fork. When "origin" isn't a fork, it is the parent and
+// the fork is the remote, if any, pointing to a fork of it. The default
+// branch is that of the parent repository.
+func syncRemotes(localRepo *github.GitHubRepo) (parent, fork *github.Remote, defaultBranch string, err error) {
+	origin, err := localRepo.RemoteByName("origin")
+	if err != nil {
+		err = fmt.Errorf("Aborted: can't find git remote origin")
+		return
+	}
+
+	originProject, err := origin.Project()
+	if err != nil {
+		err = fmt.Errorf("Aborted: the origin remote doesn't point to a GitHub repository.")
+		return
+	}
+
+	client := github.NewClient(originProject.Host)
+	repo, err := client.RepositoryDetails(originProject)
+	if err != nil {
+		return
+	}
+
+	if repo.Parent != nil {
+		defaultBranch = repo.Parent.DefaultBranch
+		parentURL, e := github.ParseURL(repo.Parent.HTMLURL)
+		if e != nil {
+			err = e
+			return
+		}
+
+		parent, err = localRepo.RemoteForProject(parentURL.Project)
+		if err != nil {
+			err = fmt.Errorf("Aborted: no git remote points to %s, the parent of %s\n(add one with `gh remote add %s`)",
+				parentURL.Project, originProject, parentURL.Project.Owner)
+			return
+		}
+		fork = origin
+
+		return
+	}
+
+	parent = origin
+	defaultBranch = repo.DefaultBranch
+
+	remotes, err := github.Remotes()
+	if err != nil {
+		return
+	}
+
+	for _, remote := range remotes {
+		project, e := remote.Project()
+		if remote.Name == origin.Name || e != nil {
+			continue
+		}
+
+		forkRepo, e := client.Repository(project)
+		if e != nil || forkRepo.Parent == nil {
+			continue
+		}
+
+		forkParentURL, e := github.ParseURL(forkRepo.Parent.HTMLURL)
+		if e == nil && strings.EqualFold(forkParentURL.Project.String(), originProject.String()) {
+			r := remote
+			fork = &r
+			break
+		}
+	}
+
+	return
+}
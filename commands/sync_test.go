@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestSyncState(t *testing.T) {
+	// c descends from b, which descends from a, and d descends from a only
+	parents := map[string]string{"b": "a", "c": "b", "d": "a"}
+	isAncestor := func(ancestor, ref string) bool {
+		for ; ref != ""; ref = parents[ref] {
+			if ref == ancestor {
+				return true
+			}
+		}
+		return false
+	}
+
+	assert.Equal(t, syncUpToDate, syncState("b", "b", isAncestor))
+	assert.Equal(t, syncUpToDate, syncState("c", "b", isAncestor))
+	assert.Equal(t, syncFastForward, syncState("a", "b", isAncestor))
+	assert.Equal(t, syncDiverged, syncState("d", "b", isAncestor))
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/jingweno/gh/github"
+	"github.com/jingweno/gh/utils"
+)
+
+var (
+	cmdTeamAccess = &Command{
+		Run:   teamAccess,
+		Usage: "team-access <COMMAND> [<OPTIONS>]",
+		Short: "Manage the access of teams to a repository in GitHub",
+		Long: `Manages the access of the teams of an organization to the project that the
+"origin" remote points to, which must be owned by the organization. See the
+usage of each <COMMAND> for its <OPTIONS>.
+`}
+
+	cmdAddTeamAccess = &Command{
+		Key:   "add",
+		Run:   addTeamAccess,
+		Usage: "team-access add <TEAM> <PERMISSION>",
+		Short: "Give a team access to a repository",
+		Long: `Gives the team <TEAM>, as named in the URL of the team, <PERMISSION> on the
+repository, one of "pull", "push" or "admin". The permission of a team that
+already has access is changed instead.
+`}
+)
+
+func init() {
+	cmdTeamAccess.Use(cmdAddTeamAccess)
+	CmdRunner.Use(cmdTeamAccess)
+}
+
+func teamAccess(cmd *Command, args *Args) {
+	utils.Check(fmt.Errorf("Missed subcommand\n%s", cmd.subCommandsUsage()))
+}
+
+/*
+  $ gh team-access add core push
+  > Gave team core push permission on sinatra/sinatra
+*/
+func addTeamAccess(cmd *Command, args *Args) {
+	if args.ParamsSize() != 2 {
+		utils.Check(fmt.Errorf("usage: %s", cmd.FormattedUsage()))
+	}
+
+	team, permission := args.GetParam(0), args.GetParam(1)
+	utils.Check(checkPermission(permission))
+
+	runInLocalRepo(func(localRepo *github.GitHubRepo, project *github.Project, gh *github.Client) {
+		if args.Noop {
+			fmt.Printf("Would give team %s %s permission on %s\n", team, permission, project)
+			return
+		}
+
+		utils.Check(gh.AddTeamRepository(project, team, permission))
+
+		fmt.Printf("Gave team %s %s permission on %s\n", team, permission, project)
+	})
+}
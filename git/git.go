@@ -142,6 +142,61 @@ func Log(sha1, sha2 string) (string, error) {
 	return outputs, nil
 }
 
+func LatestTag(ref string) (string, error) {
+	output, err := execGitCmd("describe", "--tags", "--abbrev=0", ref)
+	if err != nil {
+		return "", fmt.Errorf("No tag can describe %s", ref)
+	}
+
+	return output[0], nil
+}
+
+func LocalBranches() ([]string, error) {
+	output, err := execGitCmd("for-each-ref", "--format=%(refname)", "refs/heads")
+	if err != nil {
+		return []string{}, fmt.Errorf("Can't load local branches")
+	}
+
+	return output, nil
+}
+
+func Tags() ([]string, error) {
+	output, err := execGitCmd("tag", "-l")
+	if err != nil {
+		return []string{}, fmt.Errorf("Can't load tags")
+	}
+
+	return output, nil
+}
+
+func IsAncestor(ancestor, ref string) bool {
+	_, err := execGitCmd("merge-base", "--is-ancestor", ancestor, ref)
+	return err == nil
+}
+
+func FirstParentLog(sha1, sha2 string) ([]string, error) {
+	shaRange := sha2
+	if sha1 != "" {
+		shaRange = fmt.Sprintf("%s..%s", sha1, sha2)
+	}
+
+	output, err := execGitCmd("log", "--no-color", "--first-parent", "--format=%h %s", shaRange)
+	if err != nil {
+		return []string{}, fmt.Errorf("Can't load git log %s", shaRange)
+	}
+
+	return output, nil
+}
+
+func WorkdirName() (string, error) {
+	output, err := execGitCmd("rev-parse", "--show-toplevel")
+	if err != nil || len(output) == 0 {
+		return "", fmt.Errorf("Unable to determine git working directory")
+	}
+
+	return output[0], nil
+}
+
 func Remotes() ([]string, error) {
 	return execGitCmd("remote", "-v")
 }
@@ -150,6 +205,15 @@ func Config(name string) (string, error) {
 	return gitGetConfig(name)
 }
 
+func ConfigAll(name string) ([]string, error) {
+	output, err := execGitCmd("config", "--get-all", name)
+	if err != nil {
+		return []string{}, fmt.Errorf("Unknown config %s", name)
+	}
+
+	return output, nil
+}
+
 func GlobalConfig(name string) (string, error) {
 	return gitGetConfig("--global", name)
 }
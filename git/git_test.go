@@ -30,6 +30,11 @@ func TestGitRef(t *testing.T) {
 	assert.Equal(t, "1c1077c052d32a83aa13a8afaa4a9630d2f28ef6", gitRef)
 }
 
+func TestGitIsAncestor(t *testing.T) {
+	assert.T(t, IsAncestor("HEAD~1", "HEAD"))
+	assert.T(t, !IsAncestor("HEAD", "HEAD~1"))
+}
+
 func testGitRefList(t *testing.T) {
 	refList, err := RefList("e357a98a1a580b09d4f1d9bf613a6a51e131ef6e", "49e984e2fe86f68c386aeb133b390d39e4264ec1")
 	assert.Equal(t, nil, err)
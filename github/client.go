@@ -1,9 +1,15 @@
 package github
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/jingweno/go-octokit/octokit"
 )
@@ -14,113 +20,939 @@ const (
 	OAuthAppURL   string = "http://owenou.com/gh"
 )
 
+var (
+	ReleaseAssetURL   = octokit.Hyperlink("repos/{owner}/{repo}/releases/assets/{id}")
+	ReleaseByTagURL   = octokit.Hyperlink("repos/{owner}/{repo}/releases/tags/{tag}")
+	CombinedStatusURL = octokit.Hyperlink("repos/{owner}/{repo}/commits/{ref}/status")
+	CheckRunsURL      = octokit.Hyperlink("repos/{owner}/{repo}/commits/{ref}/check-runs")
+	AnnotationsURL    = octokit.Hyperlink("repos/{owner}/{repo}/check-runs/{id}/annotations")
+
+	GenerateRepositoryURL = octokit.Hyperlink("repos/{owner}/{repo}/generate")
+	TeamURL               = octokit.Hyperlink("orgs/{org}/teams/{team}")
+	CurrentUserURL        = octokit.Hyperlink("user")
+	UserRepositoriesURL   = octokit.Hyperlink("users/{user}/repos")
+
+	GistsURL  = octokit.Hyperlink("gists{/id}")
+	SearchURL = octokit.Hyperlink("search/{type}")
+
+	NotificationsURL      = octokit.Hyperlink("notifications")
+	RepoNotificationsURL  = octokit.Hyperlink("repos/{owner}/{repo}/notifications")
+	NotificationThreadURL = octokit.Hyperlink("notifications/threads/{id}")
+
+	PublicKeysURL = octokit.Hyperlink("user/keys{/id}")
+
+	CollaboratorsURL  = octokit.Hyperlink("repos/{owner}/{repo}/collaborators{/user}")
+	TeamRepositoryURL = octokit.Hyperlink("orgs/{org}/teams/{team}/repos/{owner}/{repo}")
+)
+
+// Issues and the wiki are enabled unless HasIssues or HasWiki point to false.
+type RepositoryParams struct {
+	Name              string `json:"name,omitempty"`
+	Description       string `json:"description,omitempty"`
+	Homepage          string `json:"homepage,omitempty"`
+	Private           bool   `json:"private,omitempty"`
+	HasIssues         *bool  `json:"has_issues,omitempty"`
+	HasWiki           *bool  `json:"has_wiki,omitempty"`
+	AutoInit          bool   `json:"auto_init,omitempty"`
+	GitignoreTemplate string `json:"gitignore_template,omitempty"`
+	LicenseTemplate   string `json:"license_template,omitempty"`
+	TeamID            int    `json:"team_id,omitempty"`
+}
+
+// Only the settings that aren't nil are changed.
+type RepositoryEditParams struct {
+	Description         *string `json:"description,omitempty"`
+	Homepage            *string `json:"homepage,omitempty"`
+	DefaultBranch       *string `json:"default_branch,omitempty"`
+	Private             *bool   `json:"private,omitempty"`
+	HasIssues           *bool   `json:"has_issues,omitempty"`
+	HasWiki             *bool   `json:"has_wiki,omitempty"`
+	AllowSquashMerge    *bool   `json:"allow_squash_merge,omitempty"`
+	AllowMergeCommit    *bool   `json:"allow_merge_commit,omitempty"`
+	AllowRebaseMerge    *bool   `json:"allow_rebase_merge,omitempty"`
+	DeleteBranchOnMerge *bool   `json:"delete_branch_on_merge,omitempty"`
+	Archived            *bool   `json:"archived,omitempty"`
+}
+
+type Team struct {
+	ID   int    `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	Slug string `json:"slug,omitempty"`
+}
+
+// The repositories of an owner as listed by the API, which unlike
+// octokit.Repository tells whether a repository is archived.
+type RepositorySummary struct {
+	Name     string `json:"name,omitempty"`
+	FullName string `json:"full_name,omitempty"`
+	Private  bool   `json:"private,omitempty"`
+	Fork     bool   `json:"fork,omitempty"`
+	Archived bool   `json:"archived,omitempty"`
+}
+
+// A repository as the API returns it when it's got by name, which unlike
+// octokit.Repository tells its default branch, and that of its parent.
+type RepositoryDetails struct {
+	octokit.Repository
+	DefaultBranch string             `json:"default_branch,omitempty"`
+	Parent        *RepositoryDetails `json:"parent,omitempty"`
+}
+
+// The same type is used to create and edit a gist, where only the files given
+// are changed. The content of a file is only included when getting a gist by
+// its ID.
+type Gist struct {
+	ID          string              `json:"id,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Public      bool                `json:"public,omitempty"`
+	HTMLURL     string              `json:"html_url,omitempty"`
+	GitPullURL  string              `json:"git_pull_url,omitempty"`
+	Files       map[string]GistFile `json:"files,omitempty"`
+	UpdatedAt   *time.Time          `json:"updated_at,omitempty"`
+}
+
+type GistFile struct {
+	Filename  string `json:"filename,omitempty"`
+	Size      int    `json:"size,omitempty"`
+	RawURL    string `json:"raw_url,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+type CodeSearchResult struct {
+	Name       string             `json:"name,omitempty"`
+	Path       string             `json:"path,omitempty"`
+	HTMLURL    string             `json:"html_url,omitempty"`
+	Repository octokit.Repository `json:"repository,omitempty"`
+}
+
+type searchPage struct {
+	TotalCount int             `json:"total_count"`
+	Items      json.RawMessage `json:"items"`
+}
+
+type Notification struct {
+	ID         string              `json:"id,omitempty"`
+	Unread     bool                `json:"unread,omitempty"`
+	Reason     string              `json:"reason,omitempty"`
+	UpdatedAt  *time.Time          `json:"updated_at,omitempty"`
+	Subject    NotificationSubject `json:"subject,omitempty"`
+	Repository octokit.Repository  `json:"repository,omitempty"`
+}
+
+type NotificationSubject struct {
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url,omitempty"`
+	Type  string `json:"type,omitempty"`
+}
+
+type PublicKey struct {
+	ID        int        `json:"id,omitempty"`
+	Title     string     `json:"title,omitempty"`
+	Key       string     `json:"key,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type Collaborator struct {
+	Login       string `json:"login,omitempty"`
+	Permissions struct {
+		Admin bool `json:"admin,omitempty"`
+		Push  bool `json:"push,omitempty"`
+		Pull  bool `json:"pull,omitempty"`
+	} `json:"permissions,omitempty"`
+}
+
+// The highest permission of the collaborator, one of "admin", "push" or
+// "pull".
+func (c *Collaborator) Permission() string {
+	switch {
+	case c.Permissions.Admin:
+		return "admin"
+	case c.Permissions.Push:
+		return "push"
+	}
+
+	return "pull"
+}
+
+type CombinedStatus struct {
+	State    string     `json:"state,omitempty"`
+	Sha      string     `json:"sha,omitempty"`
+	Statuses []CIStatus `json:"statuses,omitempty"`
+}
+
+type CIStatus struct {
+	State       string     `json:"state,omitempty"`
+	Context     string     `json:"context,omitempty"`
+	Description string     `json:"description,omitempty"`
+	TargetURL   string     `json:"target_url,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+type CheckRun struct {
+	ID         int64  `json:"id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Conclusion string `json:"conclusion,omitempty"`
+	HTMLURL    string `json:"html_url,omitempty"`
+	DetailsURL string `json:"details_url,omitempty"`
+
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	Output struct {
+		Title            string `json:"title,omitempty"`
+		AnnotationsCount int    `json:"annotations_count,omitempty"`
+	} `json:"output,omitempty"`
+}
+
+type CheckAnnotation struct {
+	Path            string `json:"path,omitempty"`
+	StartLine       int    `json:"start_line,omitempty"`
+	AnnotationLevel string `json:"annotation_level,omitempty"`
+	Title           string `json:"title,omitempty"`
+	Message         string `json:"message,omitempty"`
+}
+
 type ClientError struct {
 	error
 }
 
-func (e *ClientError) Error() string {
-	return e.error.Error()
+func (e *ClientError) Error() string {
+	return e.error.Error()
+}
+
+func (e *ClientError) Is2FAError() bool {
+	re, ok := e.error.(*octokit.ResponseError)
+	return ok && re.Type == octokit.ErrorOneTimePasswordRequired
+}
+
+// Server errors are worth retrying, other API errors would fail the same
+// way again. Errors that didn't come from the API, such as network failures
+// or a proxy's HTML error page, are considered transient too.
+func (e *ClientError) IsTransient() bool {
+	re, ok := e.error.(*octokit.ResponseError)
+	if !ok {
+		return true
+	}
+
+	switch re.Type {
+	case octokit.ErrorServerError, octokit.ErrorInternalServerError,
+		octokit.ErrorBadGateway, octokit.ErrorServiceUnavailable:
+		return true
+	}
+
+	return false
+}
+
+// The requests that octokit can't make, such as downloading the content of a
+// release asset, share the HTTP client of octokit and so go through the same
+// proxy.
+var httpClient = &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment}}
+
+type Client struct {
+	Credentials *Credentials
+}
+
+func (client *Client) PullRequest(project *Project, id string) (pr *octokit.PullRequest, err error) {
+	url, err := octokit.PullRequestsURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name, "number": id})
+	if err != nil {
+		return
+	}
+
+	pr, result := client.octokit().PullRequests(client.requestURL(url)).One()
+	if result.HasError() {
+		err = fmt.Errorf("Error getting pull request: %s", result.Err)
+	}
+
+	return
+}
+
+func (client *Client) CreatePullRequest(project *Project, base, head, title, body string) (pr *octokit.PullRequest, err error) {
+	url, err := octokit.PullRequestsURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name})
+	if err != nil {
+		return
+	}
+
+	params := octokit.PullRequestParams{Base: base, Head: head, Title: title, Body: body}
+	pr, result := client.octokit().PullRequests(client.requestURL(url)).Create(params)
+	if result.HasError() {
+		err = fmt.Errorf("Error creating pull request: %s", result.Err)
+	}
+
+	return
+}
+
+func (client *Client) CreatePullRequestForIssue(project *Project, base, head, issue string) (pr *octokit.PullRequest, err error) {
+	url, err := octokit.PullRequestsURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name})
+	if err != nil {
+		return
+	}
+
+	params := octokit.PullRequestForIssueParams{Base: base, Head: head, Issue: issue}
+	pr, result := client.octokit().PullRequests(client.requestURL(url)).Create(params)
+	if result.HasError() {
+		err = fmt.Errorf("Error creating pull request: %s", result.Err)
+	}
+
+	return
+}
+
+func (client *Client) Repository(project *Project) (repo *octokit.Repository, err error) {
+	url, err := octokit.RepositoryURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name})
+	if err != nil {
+		return
+	}
+
+	repo, result := client.octokit().Repositories(client.requestURL(url)).One()
+	if result.HasError() {
+		err = fmt.Errorf("Error getting repository: %s", result.Err)
+	}
+
+	return
+}
+
+func (client *Client) RepositoryDetails(project *Project) (repo *RepositoryDetails, err error) {
+	url, err := octokit.RepositoryURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name})
+	if err != nil {
+		return
+	}
+
+	repo = &RepositoryDetails{}
+	_, err = client.request("GET", client.requestURL(url), nil, repo)
+	if err != nil {
+		err = fmt.Errorf("Error getting repository: %s", err)
+	}
+
+	return
+}
+
+func (client *Client) DeleteRepository(project *Project) (err error) {
+	url, err := octokit.RepositoryURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name})
+	if err != nil {
+		return
+	}
+
+	// The API only tells "Must have admin rights" when the scope is missing
+	err = client.checkScope(fmt.Sprintf("deleting repository %s", project), "delete_repo")
+	if err != nil {
+		return
+	}
+
+	_, err = client.request("DELETE", client.requestURL(url), nil, nil)
+	if err != nil {
+		err = fmt.Errorf("Error deleting repository %s: %s", project, err)
+	}
+
+	return
+}
+
+// Scopes returns the scopes of the OAuth token as the API lists them in the
+// "X-OAuth-Scopes" header, or nil if the API doesn't list them.
+func (client *Client) Scopes() (scopes []string, err error) {
+	url, err := CurrentUserURL.Expand(nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := client.request("GET", client.requestURL(url), nil, nil)
+	if err != nil {
+		err = fmt.Errorf("Error getting OAuth scopes: %s", err)
+		return
+	}
+
+	header, ok := resp.Header[http.CanonicalHeaderKey("X-OAuth-Scopes")]
+	if !ok {
+		return
+	}
+
+	scopes = []string{}
+	for _, scope := range strings.Split(strings.Join(header, ","), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return
+}
+
+// The API doesn't tell that a request failed because the OAuth token is
+// missing a scope, so the scopes are checked beforehand. The check passes
+// when the token has any of the scopes, or when the API doesn't tell the
+// scopes of the token.
+func (client *Client) checkScope(action string, scopes ...string) error {
+	granted, err := client.Scopes()
+	if err != nil {
+		return err
+	}
+	if granted == nil {
+		return nil
+	}
+
+	for _, scope := range scopes {
+		if includesScope(granted, scope) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Error %s: the OAuth token is missing the %q scope\n(grant it to the token on https://%s/settings/tokens, or delete the token there and from %s for gh to create a new one)", action, scopes[0], client.Credentials.Host, configsFile())
+}
+
+func includesScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (client *Client) EditRepository(project *Project, params RepositoryEditParams) (repo *octokit.Repository, err error) {
+	url, err := octokit.RepositoryURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name})
+	if err != nil {
+		return
+	}
+
+	repo = &octokit.Repository{}
+	_, err = client.request("PATCH", client.requestURL(url), params, repo)
+	if err != nil {
+		err = fmt.Errorf("Error editing repository %s: %s", project, err)
+	}
+
+	return
+}
+
+func (client *Client) ArchiveRepository(project *Project) (repo *octokit.Repository, err error) {
+	archived := true
+
+	return client.EditRepository(project, RepositoryEditParams{Archived: &archived})
+}
+
+func (client *Client) IsRepositoryExist(project *Project) bool {
+	repo, err := client.Repository(project)
+
+	return err == nil && repo != nil
+}
+
+func (client *Client) CreateRepository(project *Project, params RepositoryParams) (repo *octokit.Repository, err error) {
+	var repoURL octokit.Hyperlink
+	if project.Owner != client.Credentials.User {
+		repoURL = octokit.OrgRepositoriesURL
+	} else {
+		repoURL = octokit.UserRepositoriesURL
+	}
+
+	url, err := repoURL.Expand(octokit.M{"org": project.Owner})
+	if err != nil {
+		return
+	}
+
+	params.Name = project.Name
+	repo, result := client.octokit().Repositories(client.requestURL(url)).Create(params)
+	if result.HasError() {
+		if result.Response == nil || result.Response.StatusCode == 500 {
+			err = fmt.Errorf("Error creating repository: Internal Server Error (HTTP 500)")
+		} else {
+			err = fmt.Errorf("Error creating repository: %v", result.Err)
+		}
+	}
+
+	return
+}
+
+// The new repository gets the files and directories of the template
+// repository, but not its settings.
+func (client *Client) GenerateRepository(template, project *Project, params RepositoryParams) (repo *octokit.Repository, err error) {
+	url, err := GenerateRepositoryURL.Expand(octokit.M{"owner": template.Owner, "repo": template.Name})
+	if err != nil {
+		return
+	}
+
+	input := map[string]interface{}{
+		"owner":       project.Owner,
+		"name":        project.Name,
+		"description": params.Description,
+		"private":     params.Private,
+	}
+
+	repo = &octokit.Repository{}
+	_, err = client.request("POST", client.requestURL(url), input, repo)
+	if err != nil {
+		err = fmt.Errorf("Error creating repository from template %s: %s", template, err)
+	}
+
+	return
+}
+
+func (client *Client) Team(org, slug string) (team *Team, err error) {
+	url, err := TeamURL.Expand(octokit.M{"org": org, "team": slug})
+	if err != nil {
+		return
+	}
+
+	team = &Team{}
+	_, err = client.request("GET", client.requestURL(url), nil, team)
+	if err != nil {
+		err = fmt.Errorf("Error getting team %s of %s: %s", slug, org, err)
+	}
+
+	return
+}
+
+// Lists the repositories of an organization or a user. The private
+// repositories of the authenticated user are included as well.
+func (client *Client) OwnerRepositories(owner string) (repos []RepositorySummary, err error) {
+	userURL, err := octokit.UserURL.Expand(octokit.M{"user": owner})
+	if err != nil {
+		return
+	}
+
+	user := &octokit.User{}
+	_, err = client.request("GET", client.requestURL(userURL), nil, user)
+	if err != nil {
+		err = fmt.Errorf("Error getting user %s: %s", owner, err)
+		return
+	}
+
+	var url *url.URL
+	query := "per_page=100"
+	switch {
+	case user.Type == "Organization":
+		url, err = octokit.OrgRepositoriesURL.Expand(octokit.M{"org": owner})
+	case client.Credentials != nil && strings.EqualFold(owner, client.Credentials.User):
+		url, err = octokit.UserRepositoriesURL.Expand(nil)
+		query += "&affiliation=owner"
+	default:
+		url, err = UserRepositoriesURL.Expand(octokit.M{"user": owner})
+	}
+	if err != nil {
+		return
+	}
+
+	url = client.requestURL(url)
+	url.RawQuery = query
+
+	for url != nil {
+		var page []RepositorySummary
+		resp, e := client.request("GET", url, nil, &page)
+		if e != nil {
+			err = fmt.Errorf("Error getting repositories of %s: %s", owner, e)
+			return
+		}
+
+		repos = append(repos, page...)
+		url = nextPageLink(resp)
+	}
+
+	return
+}
+
+func (client *Client) CreateGist(params Gist) (gist *Gist, err error) {
+	url, err := GistsURL.Expand(nil)
+	if err != nil {
+		return
+	}
+
+	gist = &Gist{}
+	_, err = client.request("POST", client.requestURL(url), params, gist)
+	if err != nil {
+		err = fmt.Errorf("Error creating gist: %s", err)
+	}
+
+	return
+}
+
+// Lists the gists of the authenticated user.
+func (client *Client) Gists() (gists []Gist, err error) {
+	url, err := GistsURL.Expand(nil)
+	if err != nil {
+		return
+	}
+
+	url = client.requestURL(url)
+	url.RawQuery = "per_page=100"
+
+	for url != nil {
+		var page []Gist
+		resp, e := client.request("GET", url, nil, &page)
+		if e != nil {
+			err = fmt.Errorf("Error getting gists: %s", e)
+			return
+		}
+
+		gists = append(gists, page...)
+		url = nextPageLink(resp)
+	}
+
+	return
+}
+
+func (client *Client) Gist(id string) (gist *Gist, err error) {
+	url, err := GistsURL.Expand(octokit.M{"id": id})
+	if err != nil {
+		return
+	}
+
+	gist = &Gist{}
+	_, err = client.request("GET", client.requestURL(url), nil, gist)
+	if err != nil {
+		err = fmt.Errorf("Error getting gist %s: %s", id, err)
+	}
+
+	return
+}
+
+// The API truncates the content of large files, whose whole content is then
+// downloaded from their raw URL. That URL doesn't need the access token, which
+// isn't sent to it.
+func (client *Client) GistFileContent(file GistFile) (content string, err error) {
+	if !file.Truncated {
+		content = file.Content
+		return
+	}
+
+	req, err := http.NewRequest("GET", file.RawURL, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("User-Agent", client.octokit().UserAgent)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("Error downloading gist file %s: %s", file.Filename, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("Error downloading gist file %s: %s", file.Filename, resp.Status)
+		return
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	content = string(body)
+
+	return
+}
+
+func (client *Client) EditGist(id string, params Gist) (gist *Gist, err error) {
+	url, err := GistsURL.Expand(octokit.M{"id": id})
+	if err != nil {
+		return
+	}
+
+	gist = &Gist{}
+	_, err = client.request("PATCH", client.requestURL(url), params, gist)
+	if err != nil {
+		err = fmt.Errorf("Error editing gist %s: %s", id, err)
+	}
+
+	return
+}
+
+func (client *Client) SearchRepositories(query string, limit int) (repos []octokit.Repository, err error) {
+	err = client.search("repositories", query, limit, func(items json.RawMessage) (int, error) {
+		var page []octokit.Repository
+		e := json.Unmarshal(items, &page)
+		repos = append(repos, page...)
+		return len(page), e
+	})
+
+	if len(repos) > limit {
+		repos = repos[:limit]
+	}
+
+	return
+}
+
+func (client *Client) SearchIssues(query string, limit int) (issues []octokit.Issue, err error) {
+	err = client.search("issues", query, limit, func(items json.RawMessage) (int, error) {
+		var page []octokit.Issue
+		e := json.Unmarshal(items, &page)
+		issues = append(issues, page...)
+		return len(page), e
+	})
+
+	if len(issues) > limit {
+		issues = issues[:limit]
+	}
+
+	return
+}
+
+func (client *Client) SearchCode(query string, limit int) (results []CodeSearchResult, err error) {
+	err = client.search("code", query, limit, func(items json.RawMessage) (int, error) {
+		var page []CodeSearchResult
+		e := json.Unmarshal(items, &page)
+		results = append(results, page...)
+		return len(page), e
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return
+}
+
+// Pages through the results of a search until there are limit items, with
+// each page of items decoded by add, which returns how many items it added.
+func (client *Client) search(kind, query string, limit int, add func(items json.RawMessage) (int, error)) (err error) {
+	perPage := 100
+	if limit < perPage {
+		perPage = limit
+	}
+	params := url.Values{"q": {query}, "per_page": {fmt.Sprintf("%d", perPage)}}
+
+	u, err := SearchURL.Expand(octokit.M{"type": kind})
+	if err != nil {
+		return
+	}
+
+	u = client.requestURL(u)
+	u.RawQuery = params.Encode()
+
+	for count := 0; u != nil && count < limit; {
+		page := searchPage{}
+		resp, e := client.request("GET", u, nil, &page)
+		if e != nil {
+			err = fmt.Errorf("Error searching %s: %s", kind, e)
+			return
+		}
+
+		n, e := add(page.Items)
+		if e != nil {
+			err = fmt.Errorf("Error searching %s: %s", kind, e)
+			return
+		}
+		if n == 0 {
+			break
+		}
+
+		count += n
+		u = nextPageLink(resp)
+	}
+
+	return
+}
+
+// Lists the unread notifications of the authenticated user, or of a single
+// project when it isn't nil. With all, read notifications are listed as well.
+func (client *Client) Notifications(project *Project, all, participating bool) (notifications []Notification, err error) {
+	u, err := notificationsURL(project)
+	if err != nil {
+		return
+	}
+
+	u = client.requestURL(u)
+	u.RawQuery = url.Values{
+		"all":           {fmt.Sprintf("%t", all)},
+		"participating": {fmt.Sprintf("%t", participating)},
+		"per_page":      {"100"},
+	}.Encode()
+
+	for u != nil {
+		var page []Notification
+		resp, e := client.request("GET", u, nil, &page)
+		if e != nil {
+			err = fmt.Errorf("Error getting notifications: %s", e)
+			return
+		}
+
+		notifications = append(notifications, page...)
+		u = nextPageLink(resp)
+	}
+
+	return
 }
 
-func (e *ClientError) Is2FAError() bool {
-	re, ok := e.error.(*octokit.ResponseError)
-	return ok && re.Type == octokit.ErrorOneTimePasswordRequired
+func (client *Client) MarkNotificationRead(id string) (err error) {
+	url, err := NotificationThreadURL.Expand(octokit.M{"id": id})
+	if err != nil {
+		return
+	}
+
+	_, err = client.request("PATCH", client.requestURL(url), map[string]string{}, nil)
+	if err != nil {
+		err = fmt.Errorf("Error marking notification %s as read: %s", id, err)
+	}
+
+	return
 }
 
-type Client struct {
-	Credentials *Credentials
+// Marks all the notifications as read, or only those of project when it
+// isn't nil.
+func (client *Client) MarkNotificationsRead(project *Project) (err error) {
+	url, err := notificationsURL(project)
+	if err != nil {
+		return
+	}
+
+	_, err = client.request("PUT", client.requestURL(url), map[string]string{}, nil)
+	if err != nil {
+		err = fmt.Errorf("Error marking notifications as read: %s", err)
+	}
+
+	return
 }
 
-func (client *Client) PullRequest(project *Project, id string) (pr *octokit.PullRequest, err error) {
-	url, err := octokit.PullRequestsURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name, "number": id})
+func notificationsURL(project *Project) (*url.URL, error) {
+	if project == nil {
+		return NotificationsURL.Expand(nil)
+	}
+
+	return RepoNotificationsURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name})
+}
+
+func (client *Client) PublicKeys() (keys []PublicKey, err error) {
+	err = client.checkScope("getting SSH keys", "read:public_key", "write:public_key", "admin:public_key")
 	if err != nil {
 		return
 	}
 
-	pr, result := client.octokit().PullRequests(client.requestURL(url)).One()
-	if result.HasError() {
-		err = fmt.Errorf("Error getting pull request: %s", result.Err)
+	url, err := PublicKeysURL.Expand(nil)
+	if err != nil {
+		return
+	}
+
+	url = client.requestURL(url)
+	url.RawQuery = "per_page=100"
+
+	for url != nil {
+		var page []PublicKey
+		resp, e := client.request("GET", url, nil, &page)
+		if e != nil {
+			err = fmt.Errorf("Error getting SSH keys: %s", e)
+			return
+		}
+
+		keys = append(keys, page...)
+		url = nextPageLink(resp)
 	}
 
 	return
 }
 
-func (client *Client) CreatePullRequest(project *Project, base, head, title, body string) (pr *octokit.PullRequest, err error) {
-	url, err := octokit.PullRequestsURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name})
+func (client *Client) AddPublicKey(title, key string) (publicKey *PublicKey, err error) {
+	err = client.checkScope("adding SSH key", "write:public_key", "admin:public_key")
 	if err != nil {
 		return
 	}
 
-	params := octokit.PullRequestParams{Base: base, Head: head, Title: title, Body: body}
-	pr, result := client.octokit().PullRequests(client.requestURL(url)).Create(params)
-	if result.HasError() {
-		err = fmt.Errorf("Error creating pull request: %s", result.Err)
+	url, err := PublicKeysURL.Expand(nil)
+	if err != nil {
+		return
+	}
+
+	publicKey = &PublicKey{}
+	_, err = client.request("POST", client.requestURL(url), PublicKey{Title: title, Key: key}, publicKey)
+	if err != nil {
+		err = fmt.Errorf("Error adding SSH key: %s", err)
 	}
 
 	return
 }
 
-func (client *Client) CreatePullRequestForIssue(project *Project, base, head, issue string) (pr *octokit.PullRequest, err error) {
-	url, err := octokit.PullRequestsURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name})
+func (client *Client) DeletePublicKey(id string) (err error) {
+	err = client.checkScope(fmt.Sprintf("deleting SSH key %s", id), "admin:public_key")
 	if err != nil {
 		return
 	}
 
-	params := octokit.PullRequestForIssueParams{Base: base, Head: head, Issue: issue}
-	pr, result := client.octokit().PullRequests(client.requestURL(url)).Create(params)
-	if result.HasError() {
-		err = fmt.Errorf("Error creating pull request: %s", result.Err)
+	url, err := PublicKeysURL.Expand(octokit.M{"id": id})
+	if err != nil {
+		return
+	}
+
+	_, err = client.request("DELETE", client.requestURL(url), nil, nil)
+	if err != nil {
+		err = fmt.Errorf("Error deleting SSH key %s: %s", id, err)
 	}
 
 	return
 }
 
-func (client *Client) Repository(project *Project) (repo *octokit.Repository, err error) {
-	url, err := octokit.RepositoryURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name})
+func (client *Client) Collaborators(project *Project) (collaborators []Collaborator, err error) {
+	url, err := CollaboratorsURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name})
 	if err != nil {
 		return
 	}
 
-	repo, result := client.octokit().Repositories(client.requestURL(url)).One()
-	if result.HasError() {
-		err = fmt.Errorf("Error getting repository: %s", result.Err)
+	url = client.requestURL(url)
+	url.RawQuery = "per_page=100"
+
+	for url != nil {
+		var page []Collaborator
+		resp, e := client.request("GET", url, nil, &page)
+		if e != nil {
+			err = fmt.Errorf("Error getting collaborators of %s: %s", project, e)
+			return
+		}
+
+		collaborators = append(collaborators, page...)
+		url = nextPageLink(resp)
 	}
 
 	return
 }
 
-func (client *Client) IsRepositoryExist(project *Project) bool {
-	repo, err := client.Repository(project)
+// Users who aren't collaborators yet are invited, which is told by invited.
+// The permission of existing collaborators is changed instead.
+func (client *Client) AddCollaborator(project *Project, user, permission string) (invited bool, err error) {
+	url, err := CollaboratorsURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name, "user": user})
+	if err != nil {
+		return
+	}
 
-	return err == nil && repo != nil
+	resp, err := client.request("PUT", client.requestURL(url), map[string]string{"permission": permission}, nil)
+	if err != nil {
+		err = fmt.Errorf("Error adding collaborator %s to %s: %s", user, project, err)
+		return
+	}
+
+	invited = resp.StatusCode == http.StatusCreated
+
+	return
 }
 
-func (client *Client) CreateRepository(project *Project, description, homepage string, isPrivate bool) (repo *octokit.Repository, err error) {
-	var repoURL octokit.Hyperlink
-	if project.Owner != client.Credentials.User {
-		repoURL = octokit.OrgRepositoriesURL
-	} else {
-		repoURL = octokit.UserRepositoriesURL
+func (client *Client) RemoveCollaborator(project *Project, user string) (err error) {
+	url, err := CollaboratorsURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name, "user": user})
+	if err != nil {
+		return
 	}
 
-	url, err := repoURL.Expand(octokit.M{"org": project.Owner})
+	_, err = client.request("DELETE", client.requestURL(url), nil, nil)
+	if err != nil {
+		err = fmt.Errorf("Error removing collaborator %s from %s: %s", user, project, err)
+	}
+
+	return
+}
+
+// Gives the team of the organization that owns project access to it, or
+// changes the permission of the team when it already has access. Only
+// repositories owned by an organization have teams, which the API would
+// otherwise only tell with a 404.
+func (client *Client) AddTeamRepository(project *Project, team, permission string) (err error) {
+	url, err := TeamRepositoryURL.Expand(octokit.M{"org": project.Owner, "team": team, "owner": project.Owner, "repo": project.Name})
 	if err != nil {
 		return
 	}
 
-	params := octokit.Repository{
-		Name:        project.Name,
-		Description: description,
-		Homepage:    homepage,
-		Private:     isPrivate,
+	repo, err := client.Repository(project)
+	if err != nil {
+		return
 	}
-	repo, result := client.octokit().Repositories(client.requestURL(url)).Create(params)
-	if result.HasError() {
-		if result.Response == nil || result.Response.StatusCode == 500 {
-			err = fmt.Errorf("Error creating repository: Internal Server Error (HTTP 500)")
-		} else {
-			err = fmt.Errorf("Error creating repository: %v", result.Err)
-		}
+	if repo.Organization == nil {
+		err = fmt.Errorf("Error giving team %s access to %s: the repository isn't owned by an organization", team, project)
+		return
+	}
+
+	_, err = client.request("PUT", client.requestURL(url), map[string]string{"permission": permission}, nil)
+	if err != nil {
+		err = fmt.Errorf("Error giving team %s access to %s: %s", team, project, err)
 	}
 
 	return
@@ -132,9 +964,64 @@ func (client *Client) Releases(project *Project) (releases []octokit.Release, er
 		return
 	}
 
-	releases, result := client.octokit().Releases(client.requestURL(url)).All()
-	if result.HasError() {
-		err = fmt.Errorf("Error getting release: %s", result.Err)
+	url = client.requestURL(url)
+	url.RawQuery = "per_page=100"
+
+	c := client.octokit()
+	for url != nil {
+		page, result := c.Releases(url).All()
+		if result.HasError() {
+			err = fmt.Errorf("Error getting release: %s", result.Err)
+			return
+		}
+
+		releases = append(releases, page...)
+		url = nextPageURL(result)
+	}
+
+	return
+}
+
+func (client *Client) Release(project *Project, tagName string) (release *octokit.Release, err error) {
+	release, err = client.FindRelease(project, tagName)
+	if err == nil && release == nil {
+		err = fmt.Errorf("Unable to find release with tag name `%s'", tagName)
+	}
+
+	return
+}
+
+// FindRelease returns nil without an error when project has no release with
+// the tag. Draft releases aren't found by their tag, only in the list of all
+// releases, which is looked up when the tag isn't found.
+func (client *Client) FindRelease(project *Project, tagName string) (release *octokit.Release, err error) {
+	url, err := ReleaseByTagURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name, "tag": tagName})
+	if err != nil {
+		return
+	}
+
+	release = &octokit.Release{}
+	_, err = client.request("GET", client.requestURL(url), nil, release)
+	if err == nil {
+		return
+	}
+
+	release = nil
+	if re, ok := err.(*octokit.ResponseError); !ok || re.Type != octokit.ErrorNotFound {
+		err = fmt.Errorf("Error getting release: %s", err)
+		return
+	}
+
+	releases, err := client.Releases(project)
+	if err != nil {
+		return
+	}
+
+	for i := range releases {
+		if releases[i].TagName == tagName {
+			release = &releases[i]
+			break
+		}
 	}
 
 	return
@@ -154,48 +1041,158 @@ func (client *Client) CreateRelease(project *Project, params octokit.ReleasePara
 	return
 }
 
-func (client *Client) UploadReleaseAsset(uploadUrl *url.URL, asset *os.File, contentType string) (err error) {
+func (client *Client) UploadReleaseAsset(uploadUrl *url.URL, asset io.ReadCloser, contentType string, size int64) (err error) {
+	c := client.octokit()
+
+	result := c.Uploads(uploadUrl).UploadAsset(asset, contentType, size)
+	if result.HasError() {
+		err = &ClientError{result.Err}
+	}
+	return
+}
+
+func (client *Client) DeleteReleaseAsset(project *Project, asset *octokit.Asset) (err error) {
+	url, err := ReleaseAssetURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name, "id": asset.ID})
+	if err != nil {
+		return
+	}
+
+	_, err = client.request("DELETE", client.requestURL(url), nil, nil)
+	if err != nil {
+		err = fmt.Errorf("Error deleting asset %s: %s", asset.Name, err)
+	}
+
+	return
+}
+
+func (client *Client) DownloadReleaseAsset(asset *octokit.Asset, w io.Writer) (err error) {
+	req, err := http.NewRequest("GET", asset.URL, nil)
+	if err != nil {
+		return
+	}
+
 	c := client.octokit()
-	fileInfo, err := asset.Stat()
+	req.Header.Set("Accept", "application/octet-stream")
+	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Set("Authorization", c.AuthMethod.String())
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
+		err = fmt.Errorf("Error downloading asset %s: %s", asset.Name, err)
 		return
 	}
+	defer resp.Body.Close()
 
-	result := c.Uploads(uploadUrl).UploadAsset(asset, contentType, fileInfo.Size())
-	if result.HasError() {
-		err = fmt.Errorf("Error uploading asset: %s", result.Err)
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("Error downloading asset %s: %s", asset.Name, resp.Status)
+		return
 	}
+
+	_, err = io.Copy(w, resp.Body)
+
 	return
 }
 
-func (client *Client) CIStatus(project *Project, sha string) (status *octokit.Status, err error) {
-	url, err := octokit.StatusesURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name, "ref": sha})
+// The combined status holds the latest status of each context for the commit.
+func (client *Client) CIStatus(project *Project, sha string) (status *CombinedStatus, err error) {
+	url, err := CombinedStatusURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name, "ref": sha})
 	if err != nil {
 		return
 	}
 
-	statuses, result := client.octokit().Statuses(client.requestURL(url)).All()
-	if result.HasError() {
-		err = fmt.Errorf("Error getting CI status: %s", result.Err)
+	url = client.requestURL(url)
+	url.RawQuery = "per_page=100"
+
+	status = &CombinedStatus{}
+	_, err = client.request("GET", url, nil, status)
+	if err != nil {
+		err = fmt.Errorf("Error getting CI status: %s", err)
+	}
+
+	return
+}
+
+// CheckRuns returns nil without an error when the API has no Checks API, as
+// older GitHub Enterprise versions, which answer with a 404.
+func (client *Client) CheckRuns(project *Project, sha string) (checkRuns []CheckRun, err error) {
+	url, err := CheckRunsURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name, "ref": sha})
+	if err != nil {
+		return
+	}
+
+	url = client.requestURL(url)
+	url.RawQuery = "per_page=100"
+
+	for url != nil {
+		var page struct {
+			CheckRuns []CheckRun `json:"check_runs"`
+		}
+		resp, e := client.request("GET", url, nil, &page)
+		if e != nil {
+			if re, ok := e.(*octokit.ResponseError); !ok || re.Type != octokit.ErrorNotFound {
+				err = fmt.Errorf("Error getting check runs: %s", e)
+			}
+			checkRuns = nil
+			return
+		}
+
+		checkRuns = append(checkRuns, page.CheckRuns...)
+		url = nextPageLink(resp)
+	}
+
+	return
+}
+
+func (client *Client) CheckRunAnnotations(project *Project, checkRun *CheckRun) (annotations []CheckAnnotation, err error) {
+	url, err := AnnotationsURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name, "id": checkRun.ID})
+	if err != nil {
 		return
 	}
 
-	if len(statuses) > 0 {
-		status = &statuses[0]
+	url = client.requestURL(url)
+	url.RawQuery = "per_page=100"
+
+	_, err = client.request("GET", url, nil, &annotations)
+	if err != nil {
+		err = fmt.Errorf("Error getting annotations of %s: %s", checkRun.Name, err)
+	}
+
+	return
+}
+
+func (client *Client) CreateCIStatus(project *Project, sha string, params CIStatus) (status *CIStatus, err error) {
+	url, err := octokit.StatusesURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name, "ref": sha})
+	if err != nil {
+		return
+	}
+
+	status = &CIStatus{}
+	_, err = client.request("POST", client.requestURL(url), params, status)
+	if err != nil {
+		err = fmt.Errorf("Error creating CI status: %s", err)
 	}
 
 	return
 }
 
-func (client *Client) ForkRepository(project *Project) (repo *octokit.Repository, err error) {
+// The fork is created under the authenticated user unless org is given.
+// GitHub creates forks asynchronously, so the fork may not be available yet
+// when this returns.
+func (client *Client) ForkRepository(project *Project, org string) (repo *octokit.Repository, err error) {
 	url, err := octokit.ForksURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name})
 	if err != nil {
 		return
 	}
 
-	repo, result := client.octokit().Repositories(client.requestURL(url)).Create(nil)
-	if result.HasError() {
-		err = fmt.Errorf("Error forking repository: %s", result.Err)
+	var params interface{}
+	if org != "" {
+		params = map[string]string{"organization": org}
+	}
+
+	repo = &octokit.Repository{}
+	_, err = client.request("POST", client.requestURL(url), params, repo)
+	if err != nil {
+		err = fmt.Errorf("Error forking repository: %s", err)
 	}
 
 	return
@@ -212,6 +1209,20 @@ func (client *Client) Issues(project *Project) (issues []octokit.Issue, err erro
 	return
 }
 
+func (client *Client) Issue(project *Project, number string) (issue *octokit.Issue, err error) {
+	url, err := octokit.RepoIssuesURL.Expand(octokit.M{"owner": project.Owner, "repo": project.Name, "number": number})
+	if err != nil {
+		return
+	}
+
+	issue, result := client.octokit().Issues(client.requestURL(url)).One()
+	if result.HasError() {
+		err = fmt.Errorf("Error getting issue: %s", result.Err)
+	}
+
+	return
+}
+
 func (client *Client) CreateIssue(project *Project, title, body string, labels []string) (issue *octokit.Issue, err error) {
 	params := octokit.IssueParams{
 		Title:  title,
@@ -283,7 +1294,7 @@ func (client *Client) FindOrCreateToken(user, password, twoFactorCode string) (t
 
 	if token == "" {
 		authParam := octokit.AuthorizationParams{}
-		authParam.Scopes = append(authParam.Scopes, "repo")
+		authParam.Scopes = append(authParam.Scopes, "repo", "admin:public_key")
 		authParam.Note = "gh"
 		authParam.NoteURL = OAuthAppURL
 
@@ -299,9 +1310,40 @@ func (client *Client) FindOrCreateToken(user, password, twoFactorCode string) (t
 	return
 }
 
+// Sends a request to an API endpoint that the octokit services don't cover.
+func (client *Client) request(method string, u *url.URL, input, output interface{}) (resp *octokit.Response, err error) {
+	req, err := client.octokit().NewRequest(u.String())
+	if err != nil {
+		return
+	}
+
+	switch method {
+	case "GET":
+		resp, err = req.Get(output)
+	case "POST":
+		resp, err = req.Post(input, output)
+	case "PUT":
+		resp, err = req.Put(input, output)
+	case "PATCH":
+		resp, err = req.Patch(input, output)
+	case "DELETE":
+		resp, err = req.Delete(output)
+	default:
+		err = fmt.Errorf("Unsupported request method: %s", method)
+	}
+
+	// Decoding fails for responses without content such as "204 No Content"
+	// and "205 Reset Content"
+	if err != nil && resp != nil && (resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusResetContent) {
+		err = nil
+	}
+
+	return
+}
+
 func (client *Client) octokit() (c *octokit.Client) {
 	tokenAuth := octokit.TokenAuth{AccessToken: client.Credentials.AccessToken}
-	c = octokit.NewClientWith(client.apiEndpoint(), nil, tokenAuth)
+	c = octokit.NewClientWith(client.apiEndpoint(), httpClient, tokenAuth)
 
 	return
 }
@@ -310,6 +1352,26 @@ func (client *Client) requestURL(u *url.URL) (uu *url.URL) {
 	uu = u
 	if client.Credentials != nil && client.Credentials.Host != GitHubHost {
 		uu, _ = url.Parse(fmt.Sprintf("/api/v3/%s", u.Path))
+		uu.RawQuery = u.RawQuery
+	}
+
+	return
+}
+
+// The next page link is absolute and already points to the right API
+// endpoint, so it mustn't go through requestURL again.
+func nextPageURL(result *octokit.Result) (u *url.URL) {
+	if result.NextPage != nil {
+		u, _ = result.NextPage.Expand(nil)
+	}
+
+	return
+}
+
+// Same as nextPageURL for the responses of client.request.
+func nextPageLink(resp *octokit.Response) (u *url.URL) {
+	if link, ok := resp.MediaHeader.Relations["next"]; ok {
+		u, _ = octokit.Hyperlink(link).Expand(nil)
 	}
 
 	return
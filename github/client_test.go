@@ -1,7 +1,14 @@
 package github
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"github.com/bmizerany/assert"
+	"github.com/jingweno/go-octokit/octokit"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 )
 
@@ -15,3 +22,530 @@ func TestClient_ApiEndpoint(t *testing.T) {
 	gh = &Client{Credentials: &Credentials{Host: "http://github.corporate.com"}}
 	assert.Equal(t, "http://github.corporate.com", gh.apiEndpoint())
 }
+
+func TestClient_CreateCIStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/repos/jingweno/gh/statuses/a1b2c3d", r.URL.Path)
+
+		var params map[string]string
+		json.NewDecoder(r.Body).Decode(&params)
+		assert.Equal(t, map[string]string{"state": "success", "context": "deploy"}, params)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"state": "success", "context": "deploy"}`)
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+	project := &Project{Owner: "jingweno", Name: "gh", Host: "github.com"}
+
+	status, err := gh.CreateCIStatus(project, "a1b2c3d", CIStatus{State: "success", Context: "deploy"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "success", status.State)
+	assert.Equal(t, "deploy", status.Context)
+}
+
+func TestClient_Release(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/repos/jingweno/gh/releases/tags/v1.0.0":
+			fmt.Fprint(w, `{"tag_name": "v1.0.0", "name": "gh 1.0.0"}`)
+		case "/repos/jingweno/gh/releases/tags/v3.0.0":
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"message": "Server Error"}`)
+		case "/repos/jingweno/gh/releases":
+			fmt.Fprint(w, `[{"tag_name": "v1.1.0", "name": "gh 1.1.0", "draft": true}, {"tag_name": "v1.0.0", "name": "gh 1.0.0"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+	project := &Project{Owner: "jingweno", Name: "gh", Host: "github.com"}
+
+	release, err := gh.Release(project, "v1.0.0")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "gh 1.0.0", release.Name)
+
+	release, err = gh.Release(project, "v1.1.0")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "gh 1.1.0", release.Name)
+	assert.T(t, release.Draft)
+
+	release, err = gh.Release(project, "v2.0.0")
+	assert.Equal(t, "Unable to find release with tag name `v2.0.0'", err.Error())
+	assert.T(t, release == nil)
+
+	release, err = gh.FindRelease(project, "v2.0.0")
+	assert.Equal(t, nil, err)
+	assert.T(t, release == nil)
+
+	release, err = gh.FindRelease(project, "v3.0.0")
+	assert.NotEqual(t, nil, err)
+	assert.T(t, release == nil)
+}
+
+func TestClient_CheckRuns(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/repos/jingweno/gh/commits/a1b2c3d/check-runs":
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/repos/jingweno/gh/commits/a1b2c3d/check-runs?per_page=100&page=2>; rel="next"`, server.URL))
+				fmt.Fprint(w, `{"total_count": 2, "check_runs": [{"id": 1, "name": "test"}]}`)
+			} else {
+				fmt.Fprint(w, `{"total_count": 2, "check_runs": [{"id": 2, "name": "lint"}]}`)
+			}
+		case "/repos/jingweno/gh/commits/e4f5a6b/check-runs":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "API rate limit exceeded"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+	project := &Project{Owner: "jingweno", Name: "gh", Host: "github.com"}
+
+	checkRuns, err := gh.CheckRuns(project, "a1b2c3d")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(checkRuns))
+	assert.Equal(t, "lint", checkRuns[1].Name)
+
+	checkRuns, err = gh.CheckRuns(project, "e4f5a6b")
+	assert.NotEqual(t, nil, err)
+	assert.T(t, checkRuns == nil)
+
+	checkRuns, err = gh.CheckRuns(project, "0000000")
+	assert.Equal(t, nil, err)
+	assert.T(t, checkRuns == nil)
+}
+
+func TestClient_DownloadReleaseAsset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/jingweno/gh/releases/assets/1", r.URL.Path)
+		assert.Equal(t, "application/octet-stream", r.Header.Get("Accept"))
+		assert.Equal(t, "token 123", r.Header.Get("Authorization"))
+		assert.NotEqual(t, "", r.Header.Get("User-Agent"))
+
+		fmt.Fprint(w, "binary")
+	}))
+	defer server.Close()
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+	asset := &octokit.Asset{Name: "gh_linux.tar.gz", URL: server.URL + "/repos/jingweno/gh/releases/assets/1"}
+
+	var buffer bytes.Buffer
+	err := gh.DownloadReleaseAsset(asset, &buffer)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "binary", buffer.String())
+}
+
+func TestClient_CreateRepository(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/orgs/sinatra/repos", r.URL.Path)
+
+		var params map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&params)
+		assert.Equal(t, map[string]interface{}{
+			"name":             "recipes",
+			"has_issues":       false,
+			"license_template": "mit",
+			"team_id":          float64(42),
+		}, params)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"full_name": "sinatra/recipes"}`)
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", User: "jingweno", AccessToken: "123"}}
+	project := &Project{Owner: "sinatra", Name: "recipes", Host: "github.com"}
+
+	hasIssues := false
+	params := RepositoryParams{HasIssues: &hasIssues, LicenseTemplate: "mit", TeamID: 42}
+	repo, err := gh.CreateRepository(project, params)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "sinatra/recipes", repo.FullName)
+}
+
+func TestClient_DeleteRepositoryWithoutScope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/user", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-OAuth-Scopes", "repo, user")
+		fmt.Fprint(w, `{"login": "jingweno"}`)
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+	project := &Project{Owner: "jingweno", Name: "throwaway", Host: "github.com"}
+
+	err := gh.DeleteRepository(project)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, "Error deleting repository jingweno/throwaway: the OAuth token is missing the \"delete_repo\" scope\n(grant it to the token on https://github.com/settings/tokens, or delete the token there and from "+configsFile()+" for gh to create a new one)", err.Error())
+}
+
+func TestClient_EditRepository(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PATCH", r.Method)
+		assert.Equal(t, "/repos/jingweno/gh", r.URL.Path)
+
+		var params map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&params)
+		assert.Equal(t, map[string]interface{}{
+			"description":        "",
+			"allow_merge_commit": false,
+		}, params)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"full_name": "jingweno/gh"}`)
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+	project := &Project{Owner: "jingweno", Name: "gh", Host: "github.com"}
+
+	description, allowMergeCommit := "", false
+	params := RepositoryEditParams{Description: &description, AllowMergeCommit: &allowMergeCommit}
+	repo, err := gh.EditRepository(project, params)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "jingweno/gh", repo.FullName)
+}
+
+func TestClient_OwnerRepositories(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/users/sinatra":
+			fmt.Fprint(w, `{"login": "sinatra", "type": "Organization"}`)
+		case "/orgs/sinatra/repos":
+			if r.URL.Query().Get("page") == "" {
+				assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+				w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/sinatra/repos?per_page=100&page=2>; rel="next"`, server.URL))
+				fmt.Fprint(w, `[{"name": "sinatra", "full_name": "sinatra/sinatra"}]`)
+			} else {
+				fmt.Fprint(w, `[{"name": "sinatra-recipes", "full_name": "sinatra/sinatra-recipes", "archived": true}]`)
+			}
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", User: "jingweno", AccessToken: "123"}}
+
+	repos, err := gh.OwnerRepositories("sinatra")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(repos))
+	assert.Equal(t, "sinatra/sinatra", repos[0].FullName)
+	assert.Equal(t, "sinatra/sinatra-recipes", repos[1].FullName)
+	assert.T(t, repos[1].Archived)
+}
+
+func TestClient_CreateGist(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/gists", r.URL.Path)
+
+		var params map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&params)
+		assert.Equal(t, map[string]interface{}{
+			"description": "build log",
+			"files": map[string]interface{}{
+				"build.log": map[string]interface{}{"content": "FAIL\n"},
+			},
+		}, params)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": "8da7fb575debd88c54cf", "html_url": "https://gist.github.com/8da7fb575debd88c54cf"}`)
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+
+	params := Gist{Description: "build log", Files: map[string]GistFile{"build.log": GistFile{Content: "FAIL\n"}}}
+	gist, err := gh.CreateGist(params)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "https://gist.github.com/8da7fb575debd88c54cf", gist.HTMLURL)
+}
+
+func TestClient_GistFileContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/jingweno/8da7fb575debd88c54cf/raw/build.log", r.URL.Path)
+		assert.Equal(t, "", r.Header.Get("Authorization"))
+
+		fmt.Fprint(w, "FAIL\nFAIL\n")
+	}))
+	defer server.Close()
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+
+	content, err := gh.GistFileContent(GistFile{Content: "FAIL\n"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "FAIL\n", content)
+
+	file := GistFile{
+		Filename:  "build.log",
+		RawURL:    server.URL + "/jingweno/8da7fb575debd88c54cf/raw/build.log",
+		Truncated: true,
+		Content:   "FAIL\n",
+	}
+	content, err = gh.GistFileContent(file)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "FAIL\nFAIL\n", content)
+}
+
+func TestClient_SearchIssues(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/search/issues", r.URL.Path)
+		assert.Equal(t, "ci-status state:open", r.URL.Query().Get("q"))
+		assert.Equal(t, "3", r.URL.Query().Get("per_page"))
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/search/issues?q=ci-status+state%%3Aopen&per_page=3&page=2>; rel="next"`, server.URL))
+			fmt.Fprint(w, `{"total_count": 5, "items": [{"number": 1}, {"number": 2}]}`)
+		} else {
+			fmt.Fprint(w, `{"total_count": 5, "items": [{"number": 3}, {"number": 4}]}`)
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+
+	issues, err := gh.SearchIssues("ci-status state:open", 3)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(issues))
+	assert.Equal(t, 3, issues[2].Number)
+}
+
+func TestClient_Notifications(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/repos/jingweno/gh/notifications", r.URL.Path)
+		assert.Equal(t, "false", r.URL.Query().Get("all"))
+		assert.Equal(t, "true", r.URL.Query().Get("participating"))
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"id": "1234567890", "reason": "mention", "subject": {"title": "Add repo edit", "type": "PullRequest"}}]`)
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+	project := &Project{Owner: "jingweno", Name: "gh", Host: "github.com"}
+
+	notifications, err := gh.Notifications(project, false, true)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(notifications))
+	assert.Equal(t, "PullRequest", notifications[0].Subject.Type)
+}
+
+func TestClient_MarkNotificationRead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PATCH", r.Method)
+		assert.Equal(t, "/notifications/threads/1234567890", r.URL.Path)
+
+		w.WriteHeader(http.StatusResetContent)
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+
+	err := gh.MarkNotificationRead("1234567890")
+	assert.Equal(t, nil, err)
+}
+
+func TestClient_MarkNotificationsRead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "/notifications", r.URL.Path)
+
+		w.WriteHeader(http.StatusResetContent)
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+
+	err := gh.MarkNotificationsRead(nil)
+	assert.Equal(t, nil, err)
+}
+
+func TestClient_AddPublicKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/user":
+			w.Header().Set("X-OAuth-Scopes", "repo, write:public_key")
+			fmt.Fprint(w, `{"login": "jingweno"}`)
+		case "/user/keys":
+			assert.Equal(t, "POST", r.Method)
+
+			var params map[string]string
+			json.NewDecoder(r.Body).Decode(&params)
+			assert.Equal(t, map[string]string{"title": "laptop", "key": "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5"}, params)
+
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id": 1234567, "title": "laptop", "key": "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5"}`)
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+
+	key, err := gh.AddPublicKey("laptop", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1234567, key.ID)
+
+	err = gh.DeletePublicKey("1234567")
+	assert.Equal(t, "Error deleting SSH key 1234567: the OAuth token is missing the \"admin:public_key\" scope\n(grant it to the token on https://github.com/settings/tokens, or delete the token there and from "+configsFile()+" for gh to create a new one)", err.Error())
+}
+
+func TestClient_AddCollaborator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+
+		var params map[string]string
+		json.NewDecoder(r.Body).Decode(&params)
+		assert.Equal(t, map[string]string{"permission": "admin"}, params)
+
+		switch r.URL.Path {
+		case "/repos/jingweno/gh/collaborators/octocat":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id": 1, "invitee": {"login": "octocat"}}`)
+		case "/repos/jingweno/gh/collaborators/hubot":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+	project := &Project{Owner: "jingweno", Name: "gh", Host: "github.com"}
+
+	invited, err := gh.AddCollaborator(project, "octocat", "admin")
+	assert.Equal(t, nil, err)
+	assert.T(t, invited)
+
+	invited, err = gh.AddCollaborator(project, "hubot", "admin")
+	assert.Equal(t, nil, err)
+	assert.T(t, !invited)
+}
+
+func TestClient_RepositoryDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/octocat/gh", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"full_name": "octocat/gh", "default_branch": "master", "parent": {"full_name": "jingweno/gh", "default_branch": "main"}}`)
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+
+	repo, err := gh.RepositoryDetails(&Project{Owner: "octocat", Name: "gh", Host: "github.com"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "master", repo.DefaultBranch)
+	assert.Equal(t, "jingweno/gh", repo.Parent.FullName)
+	assert.Equal(t, "main", repo.Parent.DefaultBranch)
+}
+
+func TestClient_AddTeamRepository(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/repos/sinatra/sinatra":
+			fmt.Fprint(w, `{"full_name": "sinatra/sinatra", "organization": {"login": "sinatra"}}`)
+		case "/repos/jingweno/gh":
+			fmt.Fprint(w, `{"full_name": "jingweno/gh"}`)
+		case "/orgs/sinatra/teams/core/repos/sinatra/sinatra":
+			assert.Equal(t, "PUT", r.Method)
+
+			var params map[string]string
+			json.NewDecoder(r.Body).Decode(&params)
+			assert.Equal(t, map[string]string{"permission": "push"}, params)
+
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv("GH_API_HOST", server.URL)
+	defer os.Setenv("GH_API_HOST", "")
+
+	gh := &Client{Credentials: &Credentials{Host: "github.com", AccessToken: "123"}}
+
+	err := gh.AddTeamRepository(&Project{Owner: "sinatra", Name: "sinatra", Host: "github.com"}, "core", "push")
+	assert.Equal(t, nil, err)
+
+	err = gh.AddTeamRepository(&Project{Owner: "jingweno", Name: "gh", Host: "github.com"}, "core", "push")
+	assert.Equal(t, "Error giving team core access to jingweno/gh: the repository isn't owned by an organization", err.Error())
+}
@@ -71,7 +71,7 @@ func (c *Configs) PromptForPassword(host, user string) (pass string) {
 	}
 
 	fmt.Printf("%s password for %s (never stored): ", host, user)
-	if isTerminal(os.Stdout.Fd()) {
+	if IsTerminal(os.Stdout.Fd()) {
 		pass = string(gopass.GetPasswd())
 	} else {
 		fmt.Scanln(&pass)
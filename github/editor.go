@@ -135,11 +135,13 @@ func readLine(r *bufio.Reader) (string, error) {
 	return string(ln), err
 }
 
+// Outside of a git repository, such as when editing a gist, the message file
+// goes to the temporary directory instead.
 func getMessageFile(about string) (string, error) {
-	gitDir, err := git.Dir()
+	dir, err := git.Dir()
 	if err != nil {
-		return "", err
+		dir = os.TempDir()
 	}
 
-	return filepath.Join(gitDir, fmt.Sprintf("%s_EDITMSG", about)), nil
+	return filepath.Join(dir, fmt.Sprintf("%s_EDITMSG", about)), nil
 }
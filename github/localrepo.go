@@ -3,6 +3,7 @@ package github
 import (
 	"fmt"
 	"github.com/jingweno/gh/git"
+	"strings"
 )
 
 func LocalRepo() *GitHubRepo {
@@ -39,6 +40,19 @@ func (r *GitHubRepo) RemoteByName(name string) (*Remote, error) {
 	return nil, fmt.Errorf("No git remote with name %s", name)
 }
 
+func (r *GitHubRepo) RemoteForProject(project *Project) (*Remote, error) {
+	r.loadRemotes()
+
+	for _, remote := range r.remotes {
+		p, err := remote.Project()
+		if err == nil && strings.EqualFold(p.String(), project.String()) {
+			return &remote, nil
+		}
+	}
+
+	return nil, fmt.Errorf("No git remote points to %s", project)
+}
+
 func (r *GitHubRepo) remotesForPublish(owner string) (remotes []Remote) {
 	r.loadRemotes()
 
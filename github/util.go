@@ -6,6 +6,6 @@ import (
 	"code.google.com/p/go.crypto/ssh/terminal"
 )
 
-func isTerminal(fd uintptr) bool {
+func IsTerminal(fd uintptr) bool {
 	return terminal.IsTerminal(int(fd))
 }
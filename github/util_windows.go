@@ -2,6 +2,6 @@
 
 package github
 
-func isTerminal(fd uintptr) bool {
+func IsTerminal(fd uintptr) bool {
 	return true
 }